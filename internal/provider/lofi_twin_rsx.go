@@ -4,25 +4,31 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/dynamicplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"io"
-	"net/http"
+	"maps"
+	"slices"
+	"strings"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &T9LoFiTwinRsx{}
 var _ resource.ResourceWithImportState = &T9LoFiTwinRsx{}
+var _ resource.ResourceWithConfigValidators = &T9LoFiTwinRsx{}
+var _ resource.ResourceWithModifyPlan = &T9LoFiTwinRsx{}
+var _ resource.ResourceWithValidateConfig = &T9LoFiTwinRsx{}
 
 func NewT9LoFiTwinRsx() resource.Resource {
 	return &T9LoFiTwinRsx{}
@@ -30,37 +36,73 @@ func NewT9LoFiTwinRsx() resource.Resource {
 
 // T9LoFiTwinRsx defines the resource implementation.
 type T9LoFiTwinRsx struct {
-	client   *http.Client
+	reactor  *ReactorClient
+	reads    *ReadCache
 	provider *Tensor9ProviderModel
 }
 
 type T9LoFiTwinRsxModel struct {
-	Template     types.String `tfsdk:"template"`
-	TemplateFmt  types.String `tfsdk:"template_fmt"`
-	ProjectionId types.String `tfsdk:"projection_id"`
-	Vars         types.Map    `tfsdk:"vars"`
-	Schema       types.Map    `tfsdk:"schema"`
-	Outputs      types.Map    `tfsdk:"outputs"`
-	ReleaseId    types.String `tfsdk:"release_id"`
-	RsxId        types.String `tfsdk:"rsx_id"`
-	InfraId      types.String `tfsdk:"infra_id"`
-	Id           types.String `tfsdk:"id"`
+	Template      TemplateValue `tfsdk:"template"`
+	TemplateFile  types.String  `tfsdk:"template_file"`
+	TemplateHash  types.String  `tfsdk:"template_hash"`
+	TemplateFmt   types.String  `tfsdk:"template_fmt"`
+	ProjectionId  types.String  `tfsdk:"projection_id"`
+	Vars          types.Dynamic `tfsdk:"vars"`
+	SecretVars    types.Map     `tfsdk:"secret_vars"`
+	SecretHash    types.String  `tfsdk:"secret_vars_hash"`
+	Schema        types.Map     `tfsdk:"schema"`
+	Outputs       types.Map     `tfsdk:"outputs"`
+	TypedOutputs  types.Dynamic `tfsdk:"typed_outputs"`
+	SecretOutputs types.Map     `tfsdk:"secret_outputs"`
+	ReleaseId     types.String  `tfsdk:"release_id"`
+	RsxId         types.String  `tfsdk:"rsx_id"`
+	InfraId       types.String  `tfsdk:"infra_id"`
+	Id            types.String  `tfsdk:"id"`
 }
 
 type TfLoFiTemplate struct {
-	Raw string `json:"raw"`
-	Fmt string `json:"fmt"`
+	Raw    string `json:"raw,omitempty"`
+	Sha256 string `json:"sha256,omitempty"`
+	Fmt    string `json:"fmt"`
+}
+
+// TfTemplateReq asks the reactor whether it stores the template with the given sha256, or uploads the template when
+// Template is set.
+type TfTemplateReq struct {
+	ApiKey   string          `json:"apiKey"`
+	Sha256   string          `json:"sha256"`
+	Template *TfLoFiTemplate `json:"template,omitempty"`
+}
+
+type TfTemplateStat struct {
+	Sha256  string `json:"sha256"`
+	Present bool   `json:"present"`
 }
 
 type TfLoFiTwinRsx struct {
-	ReleaseId    *string                   `json:"releaseId"`
-	RsxId        *string                   `json:"rsxId"`
-	Template     *TfLoFiTemplate           `json:"template"`
-	ProjectionId *string                   `json:"projectionId"`
-	Vars         *map[string]string        `json:"vars"`
-	Schema       *map[string]TfRsxPropType `json:"schema"`
-	Outputs      *map[string]string        `json:"outputs"`
-	InfraId      *string                   `json:"infraId"`
+	ReleaseId    *string                    `json:"releaseId"`
+	RsxId        *string                    `json:"rsxId"`
+	Template     *TfLoFiTemplate            `json:"template"`
+	ProjectionId *string                    `json:"projectionId"`
+	Vars         *map[string]string         `json:"vars"`
+	SecretVars   *map[string]TfSealedSecret `json:"secretVars,omitempty"`
+	Schema       *map[string]TfRsxPropType  `json:"schema"`
+	Props        *TfRsxProps                `json:"props,omitempty"`
+	Outputs      *map[string]string         `json:"outputs"`
+	InfraId      *string                    `json:"infraId"`
+}
+
+// props returns the properties of the twin rsx, falling back to the types in its schema map for reactors that don't
+// report property metadata, and to the given properties if it reports neither.
+func (t *TfLoFiTwinRsx) props(fallback TfRsxProps) TfRsxProps {
+	switch {
+	case t.Props != nil:
+		return *t.Props
+	case t.Schema != nil:
+		return propsOfTypes(*t.Schema, fallback)
+	default:
+		return fallback
+	}
 }
 
 type TfRsxEvt struct {
@@ -76,6 +118,13 @@ type TfRsxEvtResult struct {
 	ResultType  string                `json:"resultType"`
 	LoFiTwinRsx *Delta[TfLoFiTwinRsx] `json:"loFiTwinRsx"`
 	Reason      *string               `json:"reason"`
+	// Plan is what the reactor would do to apply a Plan event, which it only simulates.
+	Plan *TfRsxPlan `json:"plan,omitempty"`
+	// Findings are the problems that the reactor found with the twin rsx of a Validate event.
+	Findings []TfRsxFinding `json:"findings,omitempty"`
+	// Warnings and Notices are messages for users about the event, e.g. deprecations or maintenance windows.
+	Warnings []string `json:"warnings,omitempty"`
+	Notices  []string `json:"notices,omitempty"`
 }
 
 type Delta[T any] struct {
@@ -83,75 +132,127 @@ type Delta[T any] struct {
 	After  *T `json:"after"`
 }
 
-type TfRsxPropType string
-
-const (
-	Bool   TfRsxPropType = "Bool"
-	I32    TfRsxPropType = "I32"
-	I64    TfRsxPropType = "I64"
-	F32    TfRsxPropType = "F32"
-	F64    TfRsxPropType = "F64"
-	Str    TfRsxPropType = "Str"
-	Secret TfRsxPropType = "Secret"
-)
-
 func (r *T9LoFiTwinRsx) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_lofi_twin"
 }
 
 func (r *T9LoFiTwinRsx) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: loFiTwinRsxSchemaVersion,
+
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Tensor9 Lo-Fidelity Digital Twin",
 
 		Attributes: map[string]schema.Attribute{
 			"template": schema.StringAttribute{
-				MarkdownDescription: "The infra template that specifies the resource to create inside the appliance",
-				Optional:            false,
-				Required:            true,
+				CustomType: TemplateType{},
+				MarkdownDescription: "The infra template that specifies the resource to create inside the appliance. Reformatting the " +
+					"template in a way that its `template_fmt` ignores, e.g. reordering the keys of a JSON template, doesn't change it. " +
+					"Exactly one of `template` and `template_file` must be set",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					templateModifier{},
+				},
+			},
+			"template_file": schema.StringAttribute{
+				MarkdownDescription: "The path of a file holding the infra template, as an alternative to `template` for large templates. " +
+					"The provider reads and sends the file; only its hash is kept in state, in `template_hash`",
+				Optional: true,
+			},
+			"template_hash": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The SHA-256 hash of the contents of `template_file`, used to detect changes to it",
+				PlanModifiers: []planmodifier.String{
+					templateHashModifier{},
+				},
 			},
 			"template_fmt": schema.StringAttribute{
-				MarkdownDescription: "The format of the template",
-				Optional:            false,
-				Required:            true,
+				MarkdownDescription: "The format of the template, one of " + templateFmtsMarkdown() + ". The template is parsed " +
+					"as this format when the configuration is validated",
+				Optional: false,
+				Required: true,
+				Validators: []validator.String{
+					templateFmtValidator{},
+				},
 			},
 			"projection_id": schema.StringAttribute{
-				MarkdownDescription: "The id of the projection (and associated appliance) to create the resource in",
+				MarkdownDescription: "The id of the projection (and associated appliance) to create the resource in. Changing it replaces the resource",
 				Optional:            false,
 				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
-			"vars": schema.MapAttribute{
-				ElementType:         types.StringType,
-				Required:            true,
-				MarkdownDescription: "A map of variables to pass to the template that specified the resource",
-				PlanModifiers: []planmodifier.Map{
-					mapplanmodifier.UseStateForUnknown(),
+			"vars": schema.DynamicAttribute{
+				Required: true,
+				MarkdownDescription: "An object of variables to pass to the template that specified the resource. Each variable may be " +
+					"any value of the property type that `schema` declares for it, e.g. a list for a `List<T>` or an object for a `Map<T>` or `Json`",
+				PlanModifiers: []planmodifier.Dynamic{
+					dynamicplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Dynamic{
+					declaredVarsValidator{},
 				},
 			},
-			"schema": schema.MapAttribute{
-				ElementType:         types.StringType,
+			"secret_vars": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+				MarkdownDescription: "A map of the variables declared as `Secret` or `sensitive` in `schema` to pass to the template. " +
+					"These are never shown in plans nor stored in state; only their hash is kept in `secret_vars_hash`. Requires Terraform 1.11 or later",
+			},
+			"secret_vars_hash": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "A salted HMAC-SHA256 hash of `secret_vars`, used to detect changes to them",
+				PlanModifiers: []planmodifier.String{
+					secretVarsHashModifier{},
+				},
+			},
+			"schema": schema.MapNestedAttribute{
 				Required:            true,
-				MarkdownDescription: "The schema describing the properties of the resource",
+				MarkdownDescription: "The schema describing the properties of the resource, mapping each property to its type and metadata",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: rsxPropAttributes(),
+				},
 				PlanModifiers: []planmodifier.Map{
 					mapplanmodifier.UseStateForUnknown(),
 				},
+				Validators: []validator.Map{
+					propDeclsValidator{},
+				},
 			},
 			"outputs": schema.MapAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
-				MarkdownDescription: "A map of outputs published by the resource upon create/update",
-				PlanModifiers: []planmodifier.Map{
-					mapplanmodifier.UseStateForUnknown(),
-				},
+				MarkdownDescription: "A map of outputs published by the resource upon create/update, except those declared as `Secret` or `sensitive` in `schema`",
+			},
+			"typed_outputs": schema.DynamicAttribute{
+				Computed: true,
+				MarkdownDescription: "An object of the outputs in `outputs`, each decoded according to the property type `schema` declares for it, " +
+					"e.g. a number for an `I32` or a list for a `List<T>`. Undeclared outputs are left as strings",
+			},
+			"secret_outputs": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				Sensitive:           true,
+				MarkdownDescription: "A map of the outputs declared as `Secret` or `sensitive` in `schema`",
 			},
 			"rsx_id": schema.StringAttribute{
 				Optional:            false,
 				Required:            true,
-				MarkdownDescription: "The rsx id of the twin rsx in the compiled twin stack",
+				MarkdownDescription: "The rsx id of the twin rsx in the compiled twin stack. Changing it replaces the resource",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"release_id": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				MarkdownDescription: "The id of the release that the twin rsx is deployed with. Set it to pin the twin rsx to a release, " +
+					"which the reactor validates; changing it upgrades the twin rsx in place. When unset, the reactor picks the release",
+			},
 			"infra_id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "The id of the infra that tracks the lifecycle and configuration of the resource",
@@ -170,6 +271,54 @@ func (r *T9LoFiTwinRsx) Schema(ctx context.Context, req resource.SchemaRequest,
 	}
 }
 
+func (r *T9LoFiTwinRsx) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		varValuesValidator{},
+		secretVarsValidator{},
+		requiredVarsValidator{},
+		templateSourceValidator{},
+		templateValidator{},
+	}
+}
+
+// ValidateConfig sends the reactor a Validate event, so that it checks the twin rsx against what only the vctrl knows,
+// e.g. the resources that templates may use, the quotas of the projection, or customer policy. The reactor is only asked
+// once the provider is configured, as it is while planning, and once every value of the config is known.
+func (r *T9LoFiTwinRsx) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	if r.reactor == nil || !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+
+	var rsxModel T9LoFiTwinRsxModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &rsxModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Nothing has been planned yet to check the template file against.
+	rsxModel.TemplateHash = types.StringUnknown()
+	twinRsx, diags := twinRsxOf(rsxModel)
+	if diags.HasError() || (rsxModel.Template.IsNull() && rsxModel.TemplateFile.IsNull()) {
+		// Configs that can't be sent to the reactor are reported by the config validators.
+		tflog.Debug(ctx, fmt.Sprintf("not asking the reactor to validate the lo fi twin resource, whose config is invalid; rsx_id=%s", rsxModel.RsxId.ValueString()))
+		return
+	}
+
+	var evt = TfRsxEvt{
+		RsxType:     "LoFiTwin",
+		EvtType:     "Validate",
+		LoFiTwinRsx: twinRsx,
+	}
+
+	evtResult, err := r.reactor.React(ctx, &evt)
+	if err != nil {
+		// Failing to validate isn't a problem with the config; anything that would fail validation fails when applied.
+		resp.Diagnostics.AddWarning("Client Error", fmt.Sprintf("Unable to validate rsx %s, got error: %s", rsxModel.RsxId.ValueString(), err))
+		return
+	}
+	resp.Diagnostics.Append(resultDiagnostics(evtResult)...)
+	resp.Diagnostics.Append(findingDiagnostics(evtResult.Findings)...)
+}
+
 func (r *T9LoFiTwinRsx) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
@@ -187,7 +336,8 @@ func (r *T9LoFiTwinRsx) Configure(ctx context.Context, req resource.ConfigureReq
 		return
 	}
 
-	r.client = providerData.Client
+	r.reactor = providerData.Reactor
+	r.reads = providerData.Reads
 	r.provider = providerData.Model
 }
 
@@ -201,77 +351,38 @@ func (r *T9LoFiTwinRsx) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	println(fmt.Sprintf("Found provider endpoint: %s", r.provider.Endpoint))
-	println(fmt.Sprintf("Found provider api_key: %s", r.provider.ApiKey))
-
 	tflog.Debug(ctx, fmt.Sprintf("Found provider endpoint: %s", r.provider.Endpoint))
-	tflog.Debug(ctx, fmt.Sprintf("Found provider api_key: %s", r.provider.ApiKey))
 
-	vars := mapToStringMap(rsxModel.Vars)
-	schema := schematize(rsxModel.Schema)
-	var evt = TfRsxEvt{
-		ApiKey:  r.provider.ApiKey.ValueString(),
-		RsxType: "LoFiTwin",
-		EvtType: "Create",
-		LoFiTwinRsx: &TfLoFiTwinRsx{
-			ReleaseId: rsxModel.ReleaseId.ValueStringPointer(),
-			RsxId:     rsxModel.RsxId.ValueStringPointer(),
-			Template: &TfLoFiTemplate{
-				Raw: rsxModel.Template.ValueString(),
-				Fmt: rsxModel.TemplateFmt.ValueString(),
-			},
-			ProjectionId: rsxModel.ProjectionId.ValueStringPointer(),
-			Vars:         &vars,
-			Schema:       &schema,
-		},
-	}
-	evtJson, err := json.Marshal(evt)
-	if err != nil {
-		resp.Diagnostics.AddError("JSON Encoding Error", fmt.Sprintf("Failed to encode request body: %s", err))
+	ctx, twinRsx, diags := r.plannedTwinRsx(ctx, &rsxModel, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	evtReq, err := http.NewRequest("POST", r.provider.Endpoint.ValueString()+"/stack/tf/react", bytes.NewReader(evtJson))
-	evtResultResp, err := r.client.Do(evtReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create rsx, got error: %s", err))
-		return
+	var evt = TfRsxEvt{
+		RsxType:     "LoFiTwin",
+		EvtType:     "Create",
+		LoFiTwinRsx: twinRsx,
 	}
 
-	evtResultBytes, err := io.ReadAll(evtResultResp.Body)
-
+	evtResult, err := r.reactor.React(ctx, &evt)
 	if err != nil {
-		resp.Diagnostics.AddError("Read Response Error", err.Error())
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create rsx, got error: %s", err))
 		return
 	}
 
-	evtResultStr := string(evtResultBytes)
-	//println("Received evt result", evtResultStr)
-	tflog.Debug(ctx, fmt.Sprintf("Create response body: %s", evtResultStr))
-
-	err = evtResultResp.Body.Close()
-	if err != nil {
-		resp.Diagnostics.AddError("Close Response Body Error", err.Error())
+	if evtResult.LoFiTwinRsx == nil || evtResult.LoFiTwinRsx.After == nil || evtResult.LoFiTwinRsx.After.InfraId == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create rsx, reactor returned no infra id; result=%s", evtResult.ResultType))
 		return
 	}
 
-	var evtResult TfRsxEvtResult
-
-	err = json.Unmarshal(evtResultBytes, &evtResult)
-	if err != nil {
-		resp.Diagnostics.AddError("JSON decode error", fmt.Sprintf("Failed to decode response JSON: %s", err))
+	resp.Diagnostics.Append(checkPinnedRelease(rsxModel.ReleaseId, evtResult.LoFiTwinRsx.After)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	tflog.Debug(ctx, fmt.Sprintf("Got tf evt result: %s", evtResultStr))
-	//println(fmt.Sprintf("Got tf evt result for tf stack reactor: %s", evtResultStr))
-
-	rsxModel.InfraId = types.StringValue(*evtResult.LoFiTwinRsx.After.InfraId)
-	rsxModel.Id = rsxModel.InfraId
-
-	outputs, diag := types.MapValueFrom(ctx, types.StringType, evtResult.LoFiTwinRsx.After.Outputs)
-	resp.Diagnostics.Append(diag...)
-	rsxModel.Outputs = outputs
+	resp.Diagnostics.Append(setComputed(ctx, &rsxModel, evtResult.LoFiTwinRsx.After)...)
+	props := evtResult.LoFiTwinRsx.After.props(schematize(rsxModel.Schema))
+	resp.Diagnostics.Append(appliedResultDiagnostics(ctx, evtResult, rsxModel.RsxId.ValueString(), props)...)
 
 	tflog.Debug(ctx, fmt.Sprintf("created an lo fi twin resource; infra_id=%s", rsxModel.InfraId.ValueString()))
 	//println(fmt.Sprintf("created lo fi twin resource; infra_id=%s; rsx_id=%s; outputs=%s", rsxModel.InfraId.ValueString(), rsxModel.RsxId.ValueString(), rsxModel.Outputs.String()))
@@ -290,7 +401,29 @@ func (r *T9LoFiTwinRsx) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	// TODO: handle read
+	// Imported resources only know their id, which is always set to the infra id.
+	infraId := rsxModel.InfraId
+	if infraId.IsNull() || infraId.IsUnknown() {
+		infraId = rsxModel.Id
+	}
+
+	rsx, err := r.reads.Get(ctx, infraId.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rsx %s, got error: %s", infraId.ValueString(), err))
+		return
+	}
+
+	if rsx == nil {
+		tflog.Debug(ctx, fmt.Sprintf("lo fi twin resource no longer exists; infra_id=%s", infraId.ValueString()))
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(setConfigured(ctx, &rsxModel, rsx)...)
+	resp.Diagnostics.Append(setComputed(ctx, &rsxModel, rsx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	// Save updated rsxModel into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &rsxModel)...)
@@ -306,7 +439,52 @@ func (r *T9LoFiTwinRsx) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	// TODO: handle update
+	// Changes that don't affect the twin rsx, e.g. reformatting its template, only need saving into state.
+	affected, diags := outputsAffected(ctx, req.Config, req.Plan, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !affected {
+		tflog.Debug(ctx, fmt.Sprintf("lo fi twin resource unchanged; infra_id=%s", rsxModel.InfraId.ValueString()))
+		resp.Diagnostics.Append(resp.State.Set(ctx, &rsxModel)...)
+		return
+	}
+
+	ctx, twinRsx, diags := r.plannedTwinRsx(ctx, &rsxModel, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var evt = TfRsxEvt{
+		RsxType:     "LoFiTwin",
+		EvtType:     "Update",
+		LoFiTwinRsx: twinRsx,
+	}
+
+	evtResult, err := r.reactor.React(ctx, &evt)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update rsx %s, got error: %s", rsxModel.InfraId.ValueString(), err))
+		return
+	}
+	// The cached read of the twin rsx predates the update.
+	r.reads.Forget(rsxModel.InfraId.ValueString())
+
+	if evtResult.LoFiTwinRsx == nil || evtResult.LoFiTwinRsx.After == nil || evtResult.LoFiTwinRsx.After.InfraId == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update rsx %s, reactor returned no infra id; result=%s", rsxModel.InfraId.ValueString(), evtResult.ResultType))
+		return
+	}
+
+	resp.Diagnostics.Append(checkPinnedRelease(rsxModel.ReleaseId, evtResult.LoFiTwinRsx.After)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(setComputed(ctx, &rsxModel, evtResult.LoFiTwinRsx.After)...)
+	props := evtResult.LoFiTwinRsx.After.props(schematize(rsxModel.Schema))
+	resp.Diagnostics.Append(updatedResultDiagnostics(ctx, evtResult, rsxModel.RsxId.ValueString(), props)...)
+
+	tflog.Debug(ctx, fmt.Sprintf("updated an lo fi twin resource; infra_id=%s", rsxModel.InfraId.ValueString()))
 
 	// Save updated rsxModel into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &rsxModel)...)
@@ -322,28 +500,358 @@ func (r *T9LoFiTwinRsx) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	// TODO: handle delete
+	var evt = TfRsxEvt{
+		RsxType: "LoFiTwin",
+		EvtType: "Delete",
+		LoFiTwinRsx: &TfLoFiTwinRsx{
+			RsxId:        rsxModel.RsxId.ValueStringPointer(),
+			ProjectionId: rsxModel.ProjectionId.ValueStringPointer(),
+			InfraId:      rsxModel.InfraId.ValueStringPointer(),
+		},
+	}
+
+	evtResult, err := r.reactor.React(ctx, &evt)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete rsx %s, got error: %s", rsxModel.InfraId.ValueString(), err))
+		return
+	}
+	r.reads.Forget(rsxModel.InfraId.ValueString())
+	resp.Diagnostics.Append(appliedResultDiagnostics(ctx, evtResult, rsxModel.RsxId.ValueString(), schematize(rsxModel.Schema))...)
+
+	// Twin rsxs that are already gone need no deleting.
+	tflog.Debug(ctx, fmt.Sprintf("deleted an lo fi twin resource; infra_id=%s; result=%s", rsxModel.InfraId.ValueString(), evtResult.ResultType))
+}
+
+// ModifyPlan plans the outputs of a changing twin rsx, which is done once for all of them here rather than by attribute
+// plan modifiers, since finding whether they're affected compares the whole twin rsx, template_hash included.
+//
+// When the provider enables reactor_plan, it also previews what creating or changing the twin rsx would do inside its
+// appliance by sending the reactor a dry-run Plan event. The reactor's predicted actions are shown as warnings and its
+// predicted outputs are planned; changes that the reactor can't apply in place plan to replace the twin rsx. This is
+// RequiresReplaceIf decided by the reactor, which attribute plan modifiers have no client to ask.
+func (r *T9LoFiTwinRsx) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan on destroy.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planned, prior T9LoFiTwinRsxModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	creating := req.State.Raw.IsNull()
+	if !creating {
+		resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+		affected, diags := outputsAffected(ctx, req.Config, req.Plan, req.State)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(planOutputs(ctx, &resp.Plan, req.Config, prior, affected)...)
+		if resp.Diagnostics.HasError() || !affected {
+			return
+		}
+	}
+
+	// Nothing to preview before the provider is configured.
+	if r.provider == nil || !r.provider.ReactorPlan.ValueBool() {
+		return
+	}
+	if !req.Config.Raw.IsFullyKnown() {
+		// The reactor can't plan what isn't known yet, so the plan stays as the attribute plan modifiers left it.
+		tflog.Debug(ctx, fmt.Sprintf("not asking the reactor to plan the lo fi twin resource, whose config isn't known yet; rsx_id=%s", planned.RsxId.ValueString()))
+		return
+	}
+
+	ctx, twinRsx, diags := r.plannedTwinRsx(ctx, &planned, req.Config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var evt = TfRsxEvt{
+		RsxType:     "LoFiTwin",
+		EvtType:     "Plan",
+		LoFiTwinRsx: twinRsx,
+	}
+
+	evtResult, err := r.reactor.React(ctx, &evt)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to plan rsx %s, got error: %s", planned.RsxId.ValueString(), err))
+		return
+	}
+	resp.Diagnostics.Append(resultDiagnostics(evtResult)...)
+	plan := evtResult.Plan
+	if plan == nil {
+		tflog.Debug(ctx, fmt.Sprintf("reactor made no plan for the lo fi twin resource; rsx_id=%s; result=%s", planned.RsxId.ValueString(), evtResult.ResultType))
+		return
+	}
+
+	if detail := plannedActionsDetail(plan.Actions); detail != "" {
+		resp.Diagnostics.AddWarning(
+			"Planned Appliance Changes",
+			fmt.Sprintf("Applying rsx %s would make these changes inside the appliance of projection %s:\n\n%s",
+				planned.RsxId.ValueString(), planned.ProjectionId.ValueString(), detail),
+		)
+	}
+
+	if plan.Replace && !creating {
+		tflog.Debug(ctx, fmt.Sprintf("reactor requires replacing the lo fi twin resource; infra_id=%s", prior.InfraId.ValueString()))
+		// Terraform only replaces for the attributes that actually change, and then plans the replacement as a create,
+		// whose outputs the reactor is asked to predict anew.
+		resp.RequiresReplace = append(resp.RequiresReplace,
+			path.Root("template"), path.Root("template_hash"), path.Root("template_fmt"), path.Root("vars"),
+			path.Root("secret_vars_hash"), path.Root("schema"))
+		return
+	}
+	if plan.Outputs != nil {
+		resp.Diagnostics.Append(setPlannedOutputs(ctx, &resp.Plan, *plan.Outputs, schematize(planned.Schema))...)
+	}
 }
 
+// ImportState imports a twin rsx by its infra id, or by its projection_id/rsx_id, which the reactor resolves to its
+// infra id. Read then fills in everything else from the reactor.
 func (r *T9LoFiTwinRsx) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	infraId := req.ID
+	// Projection ids never contain slashes, but rsx ids may.
+	if projectionId, rsxId, found := strings.Cut(req.ID, "/"); found {
+		if projectionId == "" || rsxId == "" {
+			resp.Diagnostics.AddError(
+				"Invalid Import ID",
+				fmt.Sprintf("Expected an import id of the form infra_id or projection_id/rsx_id, got: %q", req.ID),
+			)
+			return
+		}
+
+		var evt = TfRsxEvt{
+			RsxType: "LoFiTwin",
+			EvtType: "Read",
+			LoFiTwinRsx: &TfLoFiTwinRsx{
+				ProjectionId: &projectionId,
+				RsxId:        &rsxId,
+			},
+		}
+
+		evtResult, err := r.reactor.React(ctx, &evt)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rsx %s in projection %s, got error: %s", rsxId, projectionId, err))
+			return
+		}
+		if evtResult.ResultType == "NotFound" || evtResult.LoFiTwinRsx == nil || evtResult.LoFiTwinRsx.After == nil ||
+			evtResult.LoFiTwinRsx.After.InfraId == nil {
+			resp.Diagnostics.AddError(
+				"Cannot Import Non-Existent Resource",
+				fmt.Sprintf("The reactor knows no rsx %s in projection %s; result=%s", rsxId, projectionId, evtResult.ResultType),
+			)
+			return
+		}
+		infraId = *evtResult.LoFiTwinRsx.After.InfraId
+		tflog.Debug(ctx, fmt.Sprintf("resolved the lo fi twin resource to import; projection_id=%s; rsx_id=%s; infra_id=%s", projectionId, rsxId, infraId))
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("infra_id"), infraId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), infraId)...)
 }
 
-func mapToStringMap(attrMap types.Map) map[string]string {
-	result := make(map[string]string)
-	for k, v := range attrMap.Elements() {
-		if strVal, ok := v.(types.String); ok {
-			result[k] = strVal.ValueString()
+// plannedTwinRsx builds the twin rsx to send to the reactor from its planned model. Secret vars are read from the
+// config, since they're write-only, and sealed so that only the projection's appliance can read them; the model keeps
+// only their hash. The returned context masks the secret vars in logs.
+func (r *T9LoFiTwinRsx) plannedTwinRsx(ctx context.Context, rsxModel *T9LoFiTwinRsxModel, config tfsdk.Config) (context.Context, *TfLoFiTwinRsx, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// Write-only attributes are only available from the config.
+	diags.Append(config.GetAttribute(ctx, path.Root("secret_vars"), &rsxModel.SecretVars)...)
+	if diags.HasError() {
+		return ctx, nil, diags
+	}
+
+	secretVars := mapToStringMap(rsxModel.SecretVars)
+	ctx = MaskSecrets(ctx, slices.Collect(maps.Values(secretVars))...)
+
+	sealedSecretVars, err := r.reactor.SealSecretVars(ctx, rsxModel.ProjectionId.ValueString(), secretVars)
+	if err != nil {
+		diags.AddAttributeError(path.Root("secret_vars"), "Encryption Error", fmt.Sprintf("Unable to encrypt secret vars, got error: %s", err))
+		return ctx, nil, diags
+	}
+
+	twinRsx, d := twinRsxOf(*rsxModel)
+	diags.Append(d...)
+	if diags.HasError() {
+		return ctx, nil, diags
+	}
+	if sealedSecretVars != nil {
+		twinRsx.SecretVars = &sealedSecretVars
+	}
+	// Keep only the hash of the secret vars, which are write-only, salting it anew unless the plan kept its salt.
+	salt := secretVarsSalt(rsxModel.SecretHash)
+	rsxModel.SecretVars = types.MapNull(types.StringType)
+	rsxModel.SecretHash = types.StringNull()
+	if len(secretVars) > 0 {
+		if salt == nil {
+			if salt, err = newSecretVarsSalt(); err != nil {
+				diags.AddAttributeError(path.Root("secret_vars"), "Hashing Error", fmt.Sprintf("Unable to salt the hash of secret vars, got error: %s", err))
+				return ctx, nil, diags
+			}
 		}
+		rsxModel.SecretHash = types.StringValue(secretVarsHash(secretVars, salt))
 	}
-	return result
+
+	return ctx, twinRsx, diags
+}
+
+// twinRsxOf builds the twin rsx to send to the reactor from its model, leaving out its secret vars.
+func twinRsxOf(rsxModel T9LoFiTwinRsxModel) (*TfLoFiTwinRsx, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	props := schematize(rsxModel.Schema)
+	schema := props.Types()
+	vars, d := encodeVars(rsxModel.Vars, props)
+	diags.Append(d...)
+	template, d := plannedTemplate(rsxModel)
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return &TfLoFiTwinRsx{
+		ReleaseId: knownStringPointer(rsxModel.ReleaseId),
+		RsxId:     rsxModel.RsxId.ValueStringPointer(),
+		Template: &TfLoFiTemplate{
+			Raw: template,
+			Fmt: rsxModel.TemplateFmt.ValueString(),
+		},
+		ProjectionId: rsxModel.ProjectionId.ValueStringPointer(),
+		Vars:         &vars,
+		Schema:       &schema,
+		Props:        &props,
+		InfraId:      knownStringPointer(rsxModel.InfraId),
+	}, diags
+}
+
+// setComputed copies the attributes that the reactor computes for a twin rsx into the model.
+func setComputed(ctx context.Context, rsxModel *T9LoFiTwinRsxModel, rsx *TfLoFiTwinRsx) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	rsxModel.InfraId = types.StringPointerValue(rsx.InfraId)
+	rsxModel.Id = rsxModel.InfraId
+	rsxModel.ReleaseId = types.StringPointerValue(rsx.ReleaseId)
+
+	props := rsx.props(schematize(rsxModel.Schema))
+	var outputs, secretOutputs map[string]string
+	if rsx.Outputs != nil {
+		outputs, secretOutputs = splitSecretOutputs(*rsx.Outputs, props)
+	}
+
+	outputsMap, d := types.MapValueFrom(ctx, types.StringType, outputs)
+	diags.Append(d...)
+	rsxModel.Outputs = outputsMap
+	secretOutputsMap, d := types.MapValueFrom(ctx, types.StringType, secretOutputs)
+	diags.Append(d...)
+	rsxModel.SecretOutputs = secretOutputsMap
+	rsxModel.TypedOutputs = types.DynamicNull()
+	if outputs != nil {
+		rsxModel.TypedOutputs = typedOutputs(outputs, props)
+	}
+
+	return diags
+}
+
+// setConfigured copies the configurable attributes that the reactor reports for a twin rsx into the model, so that
+// Read picks up changes made outside of terraform.
+func setConfigured(ctx context.Context, rsxModel *T9LoFiTwinRsxModel, rsx *TfLoFiTwinRsx) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if rsx.RsxId != nil {
+		rsxModel.RsxId = types.StringValue(*rsx.RsxId)
+	}
+	if rsx.ProjectionId != nil {
+		rsxModel.ProjectionId = types.StringValue(*rsx.ProjectionId)
+	}
+	if rsx.Template != nil {
+		if rsx.Template.Fmt != "" {
+			rsxModel.TemplateFmt = types.StringValue(rsx.Template.Fmt)
+		}
+		switch {
+		case !rsxModel.TemplateFile.IsNull():
+			// Templates read from a file are only tracked by their hash.
+			if rsx.Template.Raw != "" {
+				rsxModel.TemplateHash = types.StringValue(templateSha256(rsx.Template.Raw))
+			} else if rsx.Template.Sha256 != "" {
+				rsxModel.TemplateHash = types.StringValue(rsx.Template.Sha256)
+			}
+		case rsx.Template.Raw != "":
+			// Templates that the reactor reports by reference only are left as they are, as are reformatted ones.
+			templateFmt := TfTemplateFmt(rsxModel.TemplateFmt.ValueString())
+			if rsxModel.Template.IsNull() || !templatesEquivalent(templateFmt, rsxModel.Template.ValueString(), rsx.Template.Raw) {
+				rsxModel.Template = NewTemplateValue(rsx.Template.Raw)
+			}
+		}
+	}
+	props := rsx.props(schematize(rsxModel.Schema))
+	if rsx.Vars != nil {
+		// Secret vars are write-only; they're never read back into state.
+		rsxModel.Vars = refreshVars(rsxModel.Vars, withoutSecrets(*rsx.Vars, props), props)
+	}
+	if rsx.Schema != nil || rsx.Props != nil {
+		schema, d := refreshProps(rsxModel.Schema, props)
+		diags.Append(d...)
+		rsxModel.Schema = schema
+	}
+
+	return diags
+}
+
+// plannedTemplate reads the template of a planned twin rsx, from template_file if it's set. The file must still hash to
+// the planned template_hash, if it was known, or the applied template wouldn't be the planned one.
+func plannedTemplate(rsxModel T9LoFiTwinRsxModel) (string, diag.Diagnostics) {
+	if rsxModel.TemplateFile.IsNull() {
+		return rsxModel.Template.ValueString(), nil
+	}
+
+	template, diags := readTemplateFile(rsxModel.TemplateFile)
+	if diags.HasError() {
+		return "", diags
+	}
+	if sha := templateSha256(template); !rsxModel.TemplateHash.IsUnknown() && sha != rsxModel.TemplateHash.ValueString() {
+		diags.AddAttributeError(
+			path.Root("template_file"),
+			"Template File Changed",
+			fmt.Sprintf("The template file changed since it was planned: it hashed to %s, but now hashes to %s. Plan again to apply the changed template.",
+				rsxModel.TemplateHash.ValueString(), sha),
+		)
+	}
+	return template, diags
 }
 
-func schematize(attrMap types.Map) map[string]TfRsxPropType {
-	result := make(map[string]TfRsxPropType)
+// checkPinnedRelease checks that the reactor deployed a twin rsx with the release it's pinned to, if the planned
+// release_id is known, since terraform rejects applied values that differ from their planned ones.
+func checkPinnedRelease(planned types.String, rsx *TfLoFiTwinRsx) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if planned.IsUnknown() || planned.IsNull() || rsx.ReleaseId == nil || *rsx.ReleaseId == planned.ValueString() {
+		return diags
+	}
+	diags.AddAttributeError(
+		path.Root("release_id"),
+		"Unexpected Release",
+		fmt.Sprintf("The twin rsx is pinned to release %s, but the reactor deployed release %s.", planned.ValueString(), *rsx.ReleaseId),
+	)
+	return diags
+}
+
+// knownStringPointer is like ValueStringPointer, but also returns nil for unknown values.
+func knownStringPointer(v types.String) *string {
+	if v.IsUnknown() {
+		return nil
+	}
+	return v.ValueStringPointer()
+}
+
+func mapToStringMap(attrMap types.Map) map[string]string {
+	result := make(map[string]string)
 	for k, v := range attrMap.Elements() {
 		if strVal, ok := v.(types.String); ok {
-			result[k] = TfRsxPropType(strVal.ValueString())
+			result[k] = strVal.ValueString()
 		}
 	}
 	return result
@@ -4,128 +4,392 @@
 package provider
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 )
 
-func TestLoFiTwinRsx(t *testing.T) {
+// fakeReactor is an in-memory stand-in for the vctrl's terraform stack reactor, and the appliances behind it.
+type fakeReactor struct {
+	*httptest.Server
 
-	reactor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "POST" && r.URL.Path == "/stack/tf/react" {
-			w.Header().Set("Content-Type", "application/json")
+	mu    sync.Mutex
+	twins map[string]*TfLoFiTwinRsx
+	// The secret vars of each twin, as decrypted by its appliance.
+	secrets map[string]map[string]string
+	// The key that every projection's appliance opens sealed secrets with.
+	key *rsa.PrivateKey
+	// The number of creates, which each get a new infra id.
+	creates int
+	// The number of updates, which each deploy a new release.
+	updates int
+	// Whether the appliances can only apply template changes by replacing twins.
+	replaceTemplates bool
+}
 
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
-				http.Error(w, "failed to read body", http.StatusBadRequest)
-				return
-			}
-			defer func(Body io.ReadCloser) {
-				err := Body.Close()
-				if err != nil {
-					println(fmt.Sprintf("Error closing request body: %v\n", err))
-				}
-			}(r.Body)
+func newFakeReactor() *fakeReactor {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate projection key: %s", err))
+	}
+
+	reactor := &fakeReactor{
+		twins:   make(map[string]*TfLoFiTwinRsx),
+		secrets: make(map[string]map[string]string),
+		key:     key,
+	}
+	reactor.Server = httptest.NewServer(reactor)
+	return reactor
+}
 
-			var evt TfRsxEvt
+func (f *fakeReactor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-			err = json.Unmarshal(bodyBytes, &evt)
-			if err != nil {
-				http.Error(w, "failed to unmarshal evt", http.StatusBadRequest)
-				return
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/stack/tf/react":
+		var evt TfRsxEvt
+		if !readJson(w, r, &evt) {
+			return
+		}
+		f.react(w, &evt)
+	case r.Method == "POST" && r.URL.Path == "/stack/tf/read/bulk":
+		var bulkRead TfRsxBulkRead
+		if !readJson(w, r, &bulkRead) {
+			return
+		}
+		println(fmt.Sprintf("Reactor handling bulk read of %d rsxs", len(bulkRead.InfraIds)))
+		bulkResult := TfRsxBulkReadResult{LoFiTwinRsxs: make(map[string]*TfLoFiTwinRsx)}
+		for _, infraId := range bulkRead.InfraIds {
+			if twin, ok := f.twins[infraId]; ok {
+				bulkResult.LoFiTwinRsxs[infraId] = twin
 			}
+		}
+		writeJson(w, bulkResult)
+	case r.Method == "POST" && r.URL.Path == "/stack/tf/keys":
+		var keyReq TfProjectionKeyReq
+		if !readJson(w, r, &keyReq) {
+			return
+		}
+		der, err := x509.MarshalPKIXPublicKey(&f.key.PublicKey)
+		if err != nil {
+			http.Error(w, "failed to marshal public key", http.StatusInternalServerError)
+			return
+		}
+		writeJson(w, TfProjectionKey{
+			ProjectionId: keyReq.ProjectionId,
+			KeyId:        "key_1",
+			Alg:          SealedSecretAlg,
+			PublicKey:    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
 
-			println(fmt.Sprintf("Reactor received evt: %s %s", evt.EvtType, evt.RsxType))
+func (f *fakeReactor) react(w http.ResponseWriter, evt *TfRsxEvt) {
+	println(fmt.Sprintf("Reactor received evt: %s %s", evt.EvtType, evt.RsxType))
 
-			switch evt.EvtType {
-			case "Create":
-				println("Reactor handling Create event")
+	rsx := evt.LoFiTwinRsx
+	switch evt.EvtType {
+	case "Create":
+		println("Reactor handling Create event")
 
-				rsx := evt.LoFiTwinRsx
-				infraId := "000000000000000000000000deadbeef"
-				propertiesOut := make(map[string]string)
-				for k, v := range *rsx.Vars {
-					propertiesOut[k] = v
-				}
-				propertiesOut["new1"] = "value1"
-				propertiesOut["new2"] = "value2"
+		infraId := fmt.Sprintf("%024x%08x", 0, 0xdeadbeef+f.creates)
+		f.creates++
+		releaseId := "release_1"
+		if rsx.ReleaseId != nil {
+			releaseId = *rsx.ReleaseId
+		}
+		propertiesOut := make(map[string]string)
+		for k, v := range *rsx.Vars {
+			propertiesOut[k] = v
+		}
+		propertiesOut["new1"] = "value1"
+		propertiesOut["new2"] = "value2"
 
-				for k, v := range propertiesOut {
-					println(fmt.Sprintf("%s = %s", k, v))
-				}
+		for k, v := range propertiesOut {
+			println(fmt.Sprintf("%s = %s", k, v))
+		}
 
-				evtResult := TfRsxEvtResult{
-					EvtType:    evt.EvtType,
-					RsxType:    evt.RsxType,
-					ResultType: "Created",
-					LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{
-						Before: rsx,
-						After: &TfLoFiTwinRsx{
-							RsxId:        rsx.RsxId,
-							Template:     rsx.Template,
-							ProjectionId: rsx.ProjectionId,
-							Vars:         &propertiesOut,
-							Schema:       rsx.Schema,
-							InfraId:      &infraId,
-						},
-					},
-					Reason: nil,
-				}
+		secrets, err := f.openSecrets(rsx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.secrets[infraId] = secrets
 
-				evtResultJson, err := json.Marshal(evtResult)
-				if err != nil {
-					http.Error(w, "failed to marshal evt result", http.StatusBadRequest)
-					return
-				}
+		twin := &TfLoFiTwinRsx{
+			ReleaseId:    &releaseId,
+			RsxId:        rsx.RsxId,
+			Template:     rsx.Template,
+			ProjectionId: rsx.ProjectionId,
+			Vars:         rsx.Vars,
+			Schema:       rsx.Schema,
+			Props:        rsx.Props,
+			Outputs:      &propertiesOut,
+			InfraId:      &infraId,
+		}
+		f.twins[infraId] = twin
 
-				println("Reactor sending result", string(evtResultJson))
+		writeJson(w, TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Created",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: rsx, After: twin},
+			Reason:      nil,
+		})
+	case "Read":
+		println("Reactor handling Read event")
 
-				_, err = fmt.Fprint(w, string(evtResultJson))
-				if err != nil {
-					http.Error(w, "failed to write response", http.StatusInternalServerError)
-					return
+		var twin *TfLoFiTwinRsx
+		if rsx.InfraId != nil {
+			twin = f.twins[*rsx.InfraId]
+		} else {
+			// Twins are also found by where they're deployed.
+			for _, candidate := range f.twins {
+				if *candidate.ProjectionId == *rsx.ProjectionId && *candidate.RsxId == *rsx.RsxId {
+					twin = candidate
 				}
+			}
+		}
+		if twin == nil {
+			writeJson(w, TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "NotFound"})
+			return
+		}
+		writeJson(w, TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Read",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: twin, After: twin},
+		})
+	case "Update":
+		println("Reactor handling Update event")
 
-				return
-			case "Read":
-				println("Reactor handling Read event")
-				println("TODO: implement Read event handling")
-				return
-			case "Update":
-				println("Reactor handling Update event")
-				println("TODO: implement Update event handling")
-				return
-			case "Delete":
-				println("Reactor handling Delete event")
-				println("TODO: implement Delete event handling")
-				return
-			default:
-				http.Error(w, "unknown evt type", http.StatusBadRequest)
+		before, ok := f.twins[*rsx.InfraId]
+		if !ok {
+			writeJson(w, TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "NotFound"})
+			return
+		}
+		secrets, err := f.openSecrets(rsx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.secrets[*rsx.InfraId] = secrets
+
+		f.updates++
+		releaseId := fmt.Sprintf("release_%d", f.updates+1)
+		if rsx.ReleaseId != nil {
+			releaseId = *rsx.ReleaseId
+		}
+		propertiesOut := make(map[string]string)
+		for k, v := range *rsx.Vars {
+			propertiesOut[k] = v
+		}
+		propertiesOut["new1"] = "value1"
+		propertiesOut["new2"] = "value2"
+
+		twin := &TfLoFiTwinRsx{
+			ReleaseId:    &releaseId,
+			RsxId:        rsx.RsxId,
+			Template:     rsx.Template,
+			ProjectionId: rsx.ProjectionId,
+			Vars:         rsx.Vars,
+			Schema:       rsx.Schema,
+			Props:        rsx.Props,
+			Outputs:      &propertiesOut,
+			InfraId:      before.InfraId,
+		}
+		f.twins[*rsx.InfraId] = twin
+
+		writeJson(w, TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Updated",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: before, After: twin},
+			Notices:     []string{fmt.Sprintf("Deployed %s.", releaseId)},
+		})
+	case "Plan":
+		println("Reactor handling Plan event")
+
+		action, replace := "Create", false
+		var before *TfLoFiTwinRsx
+		if rsx.InfraId != nil {
+			var ok bool
+			if before, ok = f.twins[*rsx.InfraId]; !ok {
+				writeJson(w, TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "NotFound"})
 				return
 			}
+			templateChanged := rsx.Template.Raw != before.Template.Raw || rsx.Template.Fmt != before.Template.Fmt
+			action, replace = "Update", f.replaceTemplates && templateChanged
+			if replace {
+				action = "Replace"
+			}
+		}
+		outputs := map[string]*string{"new1": ptr("value1"), "new2": ptr("value2")}
+		for k, v := range *rsx.Vars {
+			outputs[k] = ptr(v)
 		}
+		writeJson(w, TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Planned",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: before, After: rsx},
+			Plan: &TfRsxPlan{
+				Replace: replace,
+				Actions: []TfRsxPlannedAction{{Address: "null_resource." + *rsx.RsxId, Action: action}},
+				Outputs: &outputs,
+			},
+		})
+	case "Validate":
+		println("Reactor handling Validate event")
 
-		http.NotFound(w, r)
-	}))
+		var findings []TfRsxFinding
+		if rsx.ReleaseId != nil && !strings.HasPrefix(*rsx.ReleaseId, "release_") {
+			findings = append(findings, TfRsxFinding{
+				Severity: "Error",
+				Summary:  "Unknown Release",
+				Detail:   fmt.Sprintf("There's no release %s.", *rsx.ReleaseId),
+				Path:     ptr("release_id"),
+			})
+		}
+		for k, v := range *rsx.Vars {
+			if v == "forbidden" {
+				findings = append(findings, TfRsxFinding{
+					Severity: "Error",
+					Summary:  "Forbidden Variable",
+					Detail:   fmt.Sprintf("Customer policy forbids %s.", k),
+					Path:     ptr(fmt.Sprintf("vars[%q]", k)),
+				})
+			}
+		}
+		writeJson(w, TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "Validated", Findings: findings})
+	case "Delete":
+		println("Reactor handling Delete event")
+
+		before, ok := f.twins[*rsx.InfraId]
+		if !ok {
+			writeJson(w, TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "NotFound"})
+			return
+		}
+		delete(f.twins, *rsx.InfraId)
+		delete(f.secrets, *rsx.InfraId)
+		writeJson(w, TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Deleted",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: before},
+		})
+	default:
+		http.Error(w, "unknown evt type", http.StatusBadRequest)
+	}
+}
+
+// openSecrets decrypts the sealed secret vars of a twin rsx the way its appliance would.
+func (f *fakeReactor) openSecrets(rsx *TfLoFiTwinRsx) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if rsx.SecretVars == nil {
+		return secrets, nil
+	}
+	for name, sealed := range *rsx.SecretVars {
+		secret, err := OpenSecret(f.key, *rsx.ProjectionId, name, &sealed)
+		if err != nil {
+			return nil, err
+		}
+		secrets[name] = secret
+	}
+	return secrets, nil
+}
+
+// ptr returns a pointer to a copy of v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func readJson(w http.ResponseWriter, r *http.Request, v any) bool {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return false
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			println(fmt.Sprintf("Error closing request body: %v\n", err))
+		}
+	}(r.Body)
+
+	err = json.Unmarshal(bodyBytes, v)
+	if err != nil {
+		http.Error(w, "failed to unmarshal request", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	respJson, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	println("Reactor sending result", string(respJson))
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = fmt.Fprint(w, string(respJson))
+	if err != nil {
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+func TestLoFiTwinRsx(t *testing.T) {
+	reactor := newFakeReactor()
 	defer reactor.Close()
 
 	schema := map[string]TfRsxPropType{"original1": "Str", "new1": "Str", "new2": "Str"}
+	template := `variable "original1" {}
+variable "new1" { default = "" }
+variable "new2" { default = "" }
+`
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(*terraform.State) error {
+			reactor.mu.Lock()
+			defer reactor.mu.Unlock()
+			if len(reactor.twins) != 0 {
+				return fmt.Errorf("expected the reactor to delete every twin, %d remain", len(reactor.twins))
+			}
+			return nil
+		},
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
 				Config: testAccExampleResourceConfig(
 					reactor.URL,
-					"{}",
+					template,
 					"Terraform",
 					"0000000000000000:0000000000000000:0000000000000000",
 					"rsx_a",
@@ -136,37 +400,97 @@ func TestLoFiTwinRsx(t *testing.T) {
 					statecheck.ExpectKnownValue(
 						"tensor9_lofi_twin.test_twin",
 						tfjsonpath.New("template"),
-						knownvalue.StringExact("{}"),
+						knownvalue.StringExact(template),
 					),
 				},
 			},
-			// ImportState testing
+			// ImportState testing, by infra id
 			{
 				ResourceName:      "tensor9_lofi_twin.test_twin",
 				ImportState:       true,
 				ImportStateVerify: true,
-				// This is not normally necessary, but is here because this
-				// example code does not have an actual upstream service.
-				// Once the Read method is able to refresh information from
-				// the upstream service, this can be removed.
-				ImportStateVerifyIgnore: []string{"template", "template_fmt", "projection_id", "rsx_id", "infra_id", "vars", "schema", "outputs"}, // TODO: remove this once ::Read is implemented
+			},
+			// ImportState testing, by projection id and rsx id
+			{
+				ResourceName:      "tensor9_lofi_twin.test_twin",
+				ImportState:       true,
+				ImportStateId:     "0000000000000000:0000000000000000:0000000000000000/rsx_a",
+				ImportStateVerify: true,
 			},
 			// Update and Read testing
 			{
 				Config: testAccExampleResourceConfig(
 					reactor.URL,
-					"{}",
+					template,
 					"Terraform",
 					"0000000000000000:0000000000000000:0000000000000000",
 					"rsx_a",
-					map[string]string{"original1": "value1"},
+					map[string]string{"original1": "value2"},
 					schema,
 				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("outputs")),
+					},
+				},
 				ConfigStateChecks: []statecheck.StateCheck{
 					statecheck.ExpectKnownValue(
 						"tensor9_lofi_twin.test_twin",
-						tfjsonpath.New("template"),
-						knownvalue.StringExact("{}"),
+						tfjsonpath.New("outputs").AtMapKey("original1"),
+						knownvalue.StringExact("value2"),
+					),
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("release_id"),
+						knownvalue.StringExact("release_2"),
+					),
+				},
+			},
+			// Reformatting the template changes nothing
+			{
+				Config: testAccExampleResourceConfig(
+					reactor.URL,
+					template+"\n\n",
+					"Terraform",
+					"0000000000000000:0000000000000000:0000000000000000",
+					"rsx_a",
+					map[string]string{"original1": "value2"},
+					schema,
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("outputs").AtMapKey("original1"), knownvalue.StringExact("value2")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("release_id"),
+						knownvalue.StringExact("release_2"),
+					),
+				},
+			},
+			// Moving the twin to another projection replaces it
+			{
+				Config: testAccExampleResourceConfig(
+					reactor.URL,
+					template,
+					"Terraform",
+					"0000000000000001:0000000000000000:0000000000000000",
+					"rsx_a",
+					map[string]string{"original1": "value2"},
+					schema,
+				),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("tensor9_lofi_twin.test_twin", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("infra_id"),
+						knownvalue.StringExact(fmt.Sprintf("%024x%08x", 0, 0xdeadbeef+1)),
 					),
 				},
 			},
@@ -175,6 +499,249 @@ func TestLoFiTwinRsx(t *testing.T) {
 	})
 }
 
+func TestLoFiTwinRsxValidate(t *testing.T) {
+	reactor := newFakeReactor()
+	defer reactor.Close()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccExampleResourceConfig(
+					reactor.URL,
+					`variable "original1" {}`,
+					"Terraform",
+					"0000000000000000:0000000000000000:0000000000000000",
+					"rsx_a",
+					map[string]string{"original1": "forbidden"},
+					map[string]TfRsxPropType{"original1": "Str"},
+				),
+				ExpectError: regexp.MustCompile(`Forbidden Variable`),
+			},
+		},
+	})
+}
+
+func TestLoFiTwinRsxReleasePinning(t *testing.T) {
+	reactor := newFakeReactor()
+	defer reactor.Close()
+
+	config := func(releaseId string) string {
+		config := testAccExampleResourceConfig(
+			reactor.URL,
+			`variable "original1" {}`,
+			"Terraform",
+			"0000000000000000:0000000000000000:0000000000000000",
+			"rsx_a",
+			map[string]string{"original1": "value1"},
+			map[string]TfRsxPropType{"original1": "Str"},
+		)
+		if releaseId == "" {
+			return config
+		}
+		return strings.Replace(config, `rsx_id = "rsx_a"`, fmt.Sprintf(`rsx_id = "rsx_a"`+"\n  release_id = %q", releaseId), 1)
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("release_id"), knownvalue.StringExact("release_1")),
+				},
+			},
+			// Pinning another release upgrades the twin in place
+			{
+				Config: config("release_7"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("tensor9_lofi_twin.test_twin", plancheck.ResourceActionUpdate),
+						plancheck.ExpectUnknownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("outputs")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("release_id"), knownvalue.StringExact("release_7")),
+				},
+			},
+			// Unpinning keeps the release
+			{
+				Config: config(""),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			{
+				Config:      config("nightly"),
+				ExpectError: regexp.MustCompile(`Unknown Release`),
+			},
+		},
+	})
+}
+
+func TestLoFiTwinRsxReactorPlan(t *testing.T) {
+	reactor := newFakeReactor()
+	reactor.replaceTemplates = true
+	defer reactor.Close()
+
+	schema := map[string]TfRsxPropType{"original1": "Str"}
+	config := func(template string) string {
+		return strings.Replace(
+			testAccExampleResourceConfig(
+				reactor.URL,
+				template,
+				"Terraform",
+				"0000000000000000:0000000000000000:0000000000000000",
+				"rsx_a",
+				map[string]string{"original1": "value1"},
+				schema,
+			),
+			`api_key  = "deadbeef"`,
+			`api_key  = "deadbeef"`+"\n  reactor_plan = true",
+			1,
+		)
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The reactor predicts the outputs of the twin it would create
+			{
+				Config: config(`variable "original1" {}`),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("outputs").AtMapKey("new1"), knownvalue.StringExact("value1")),
+						plancheck.ExpectKnownValue("tensor9_lofi_twin.test_twin", tfjsonpath.New("typed_outputs").AtMapKey("original1"), knownvalue.StringExact("value1")),
+					},
+				},
+			},
+			// The reactor can only apply the changed template by replacing the twin
+			{
+				Config: config(`variable "original1" { default = "" }`),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("tensor9_lofi_twin.test_twin", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+			},
+			// Reformatting the template plans no change, and doesn't ask the reactor at all
+			{
+				Config: config(`variable "original1" { default = "" }` + "\n\n"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestLoFiTwinRsxImportStateId(t *testing.T) {
+	reactor := newFakeReactor()
+	defer reactor.Close()
+	reactor.twins["infra_a"] = &TfLoFiTwinRsx{ProjectionId: ptr("projection_a"), RsxId: ptr("rsx_a"), InfraId: ptr("infra_a")}
+	reactor.twins["infra_b"] = &TfLoFiTwinRsx{ProjectionId: ptr("projection_a"), RsxId: ptr("team/app/db"), InfraId: ptr("infra_b")}
+
+	cases := []struct {
+		name     string
+		id       string
+		expected string
+		err      string
+	}{
+		{name: "infra id", id: "infra_a", expected: "infra_a"},
+		{name: "projection and rsx id", id: "projection_a/rsx_a", expected: "infra_a"},
+		{name: "rsx id with slashes", id: "projection_a/team/app/db", expected: "infra_b"},
+		{name: "empty projection id", id: "/rsx_a", err: "Invalid Import ID"},
+		{name: "empty rsx id", id: "projection_a/", err: "Invalid Import ID"},
+		{name: "unknown rsx id", id: "projection_a/rsx_b", err: "Cannot Import Non-Existent Resource"},
+		{name: "rsx id of another projection", id: "projection_b/rsx_a", err: "Cannot Import Non-Existent Resource"},
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	NewT9LoFiTwinRsx().Schema(context.Background(), fwresource.SchemaRequest{}, &schemaResp)
+	objType := schemaResp.Schema.Type().TerraformType(context.Background())
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			rsx := &T9LoFiTwinRsx{reactor: NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{})}
+			resp := &fwresource.ImportStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objType, nil)},
+			}
+			rsx.ImportState(ctx, fwresource.ImportStateRequest{ID: c.id}, resp)
+
+			if c.err != "" {
+				if resp.Diagnostics.ErrorsCount() != 1 || resp.Diagnostics.Errors()[0].Summary() != c.err {
+					t.Fatalf("expected a %q error, got %v", c.err, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			var rsxModel T9LoFiTwinRsxModel
+			if diags := resp.State.Get(ctx, &rsxModel); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if rsxModel.InfraId.ValueString() != c.expected || rsxModel.Id.ValueString() != c.expected {
+				t.Errorf("expected infra_id and id %s, got %s and %s", c.expected, rsxModel.InfraId, rsxModel.Id)
+			}
+		})
+	}
+}
+
+// testLoFiTwinConfig builds the config of a tensor9_lofi_twin from the given attribute values, leaving every other
+// attribute null.
+func testLoFiTwinConfig(t *testing.T, attrs map[string]tftypes.Value) tfsdk.Config {
+	t.Helper()
+
+	var schemaResp fwresource.SchemaResponse
+	NewT9LoFiTwinRsx().Schema(context.Background(), fwresource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	objType := schemaResp.Schema.Type().TerraformType(context.Background()).(tftypes.Object)
+	vals := make(map[string]tftypes.Value)
+	for k, attrType := range objType.AttributeTypes {
+		vals[k] = tftypes.NewValue(attrType, nil)
+	}
+	for k, v := range attrs {
+		if _, ok := vals[k]; !ok {
+			t.Fatalf("unknown attribute %s", k)
+		}
+		vals[k] = v
+	}
+
+	return tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objType, vals)}
+}
+
+// testStringMap builds a tftypes map of strings.
+func testStringMap(m map[string]string) tftypes.Value {
+	vals := make(map[string]tftypes.Value)
+	for k, v := range m {
+		vals[k] = tftypes.NewValue(tftypes.String, v)
+	}
+	return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, vals)
+}
+
+// testSchema builds the tftypes value of a schema attribute declaring properties of the given types.
+func testSchema(propTypes map[string]TfRsxPropType) tftypes.Value {
+	schema, diags := propsValue(propsOfTypes(propTypes, nil))
+	if diags.HasError() {
+		panic(fmt.Sprintf("invalid schema: %v", diags))
+	}
+	v, err := schema.ToTerraformValue(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func testAccExampleResourceConfig(
 	endpoint string,
 	template string,
@@ -201,7 +768,7 @@ func testAccExampleResourceConfig(
 	} else {
 		schemaStr = "{\n"
 		for k, v := range schema {
-			schemaStr += fmt.Sprintf(`    %q = "%s"`+"\n", k, v)
+			schemaStr += fmt.Sprintf(`    %q = { type = "%s" }`+"\n", k, v)
 		}
 		schemaStr += "}\n"
 	}
@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithUpgradeState = &T9LoFiTwinRsx{}
+
+// The version of the lofi twin rsx schema, bumped whenever the state of existing twin rsxs must be upgraded:
+//
+//	0: vars is a map of strings and schema maps each property to its type, as first released
+//	1: vars is a dynamic object, so that vars can be lists, maps and the like, schema maps each property to an object
+//	   of its type and metadata, and Secret outputs are kept in secret_outputs. template_file, template_hash,
+//	   secret_vars, secret_vars_hash, typed_outputs, secret_outputs and release_id were added after version 0 was
+//	   released, so they're first part of the state at version 1
+const loFiTwinRsxSchemaVersion = 1
+
+// T9LoFiTwinRsxModelV0 is the state of a twin rsx at schema version 0.
+type T9LoFiTwinRsxModelV0 struct {
+	Template     types.String `tfsdk:"template"`
+	TemplateFmt  types.String `tfsdk:"template_fmt"`
+	ProjectionId types.String `tfsdk:"projection_id"`
+	Vars         types.Map    `tfsdk:"vars"`
+	Schema       types.Map    `tfsdk:"schema"`
+	Outputs      types.Map    `tfsdk:"outputs"`
+	RsxId        types.String `tfsdk:"rsx_id"`
+	InfraId      types.String `tfsdk:"infra_id"`
+	Id           types.String `tfsdk:"id"`
+}
+
+func loFiTwinRsxSchemaV0() *schema.Schema {
+	stringAttr := func(required bool) schema.StringAttribute {
+		return schema.StringAttribute{Required: required, Computed: !required}
+	}
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"template":      stringAttr(true),
+			"template_fmt":  stringAttr(true),
+			"projection_id": stringAttr(true),
+			"vars":          schema.MapAttribute{ElementType: types.StringType, Required: true},
+			"schema":        schema.MapAttribute{ElementType: types.StringType, Required: true},
+			"outputs":       schema.MapAttribute{ElementType: types.StringType, Computed: true},
+			"rsx_id":        stringAttr(true),
+			"infra_id":      stringAttr(false),
+			"id":            stringAttr(false),
+		},
+	}
+}
+
+func (r *T9LoFiTwinRsx) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   loFiTwinRsxSchemaV0(),
+			StateUpgrader: upgradeLoFiTwinRsxStateV0,
+		},
+	}
+}
+
+func upgradeLoFiTwinRsxStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior T9LoFiTwinRsxModelV0
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, diags := upgradeLoFiTwinRsxModelV0(ctx, prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &current)...)
+}
+
+// upgradeLoFiTwinRsxModelV0 turns the map of string vars into an object of strings, the type HCL gives the
+// { ... } literal that the vars were written with, turns the map of property types into a map of properties of those
+// types, and moves Secret outputs into secret_outputs.
+func upgradeLoFiTwinRsxModelV0(ctx context.Context, prior T9LoFiTwinRsxModelV0) (T9LoFiTwinRsxModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	vars := types.DynamicNull()
+	if !prior.Vars.IsNull() {
+		attrTypes := make(map[string]attr.Type)
+		for k := range prior.Vars.Elements() {
+			attrTypes[k] = types.StringType
+		}
+		obj, d := types.ObjectValue(attrTypes, prior.Vars.Elements())
+		diags.Append(d...)
+		vars = types.DynamicValue(obj)
+	}
+
+	propTypes := propTypesOf(prior.Schema)
+	schema := types.MapNull(types.ObjectType{AttrTypes: rsxPropAttrTypes})
+	if !prior.Schema.IsNull() {
+		var d diag.Diagnostics
+		schema, d = propsValue(propsOfTypes(propTypes, nil))
+		diags.Append(d...)
+	}
+
+	// Secret outputs were kept in outputs, which isn't sensitive; they now belong in secret_outputs.
+	outputs, secretOutputs, typed := prior.Outputs, types.MapNull(types.StringType), types.DynamicNull()
+	if !prior.Outputs.IsNull() {
+		props := propsOfTypes(propTypes, nil)
+		plain, secret := splitSecretOutputs(mapToStringMap(prior.Outputs), props)
+		typed = typedOutputs(plain, props)
+		var d diag.Diagnostics
+		outputs, d = types.MapValueFrom(ctx, types.StringType, plain)
+		diags.Append(d...)
+		secretOutputs, d = types.MapValueFrom(ctx, types.StringType, secret)
+		diags.Append(d...)
+	}
+
+	return T9LoFiTwinRsxModel{
+		Template:      TemplateValue{StringValue: prior.Template},
+		TemplateFile:  types.StringNull(),
+		TemplateHash:  types.StringNull(),
+		TemplateFmt:   prior.TemplateFmt,
+		ProjectionId:  prior.ProjectionId,
+		Vars:          vars,
+		SecretVars:    types.MapNull(types.StringType),
+		SecretHash:    types.StringNull(),
+		Schema:        schema,
+		Outputs:       outputs,
+		TypedOutputs:  typed,
+		SecretOutputs: secretOutputs,
+		ReleaseId:     types.StringNull(),
+		RsxId:         prior.RsxId,
+		InfraId:       prior.InfraId,
+		Id:            prior.Id,
+	}, diags
+}
+
+// propTypesOf reads a schema map of property types, as it was at schema version 0.
+func propTypesOf(schema types.Map) map[string]TfRsxPropType {
+	result := make(map[string]TfRsxPropType)
+	for k, v := range mapToStringMap(schema) {
+		result[k] = TfRsxPropType(v)
+	}
+	return result
+}
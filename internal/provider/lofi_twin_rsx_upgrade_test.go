@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// loFiTwinUpgradeFixture is a state of a twin rsx at a prior schema version, as terraform stores it in state files,
+// along with the state it's expected to upgrade to at the current version. Fixtures live in
+// testdata/lofi_twin_upgrade; every prior version must have at least one.
+type loFiTwinUpgradeFixture struct {
+	Description string          `json:"description"`
+	Version     int64           `json:"version"`
+	State       json.RawMessage `json:"state"`
+	Expected    json.RawMessage `json:"expected"`
+}
+
+func TestLoFiTwinRsxUpgradeState(t *testing.T) {
+	ctx := context.Background()
+	server := providerserver.NewProtocol6(New("test")())()
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsxSchema := schemaResp.ResourceSchemas["tensor9_lofi_twin"]
+	if rsxSchema.Version != loFiTwinRsxSchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", loFiTwinRsxSchemaVersion, rsxSchema.Version)
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "lofi_twin_upgrade", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covered := make(map[int64]bool)
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture loFiTwinUpgradeFixture
+			if err := json.Unmarshal(b, &fixture); err != nil {
+				t.Fatalf("invalid fixture: %s", err)
+			}
+			covered[fixture.Version] = true
+
+			resp, err := server.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{
+				TypeName: "tensor9_lofi_twin",
+				Version:  fixture.Version,
+				RawState: &tfprotov6.RawState{JSON: fixture.State},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			upgraded, err := resp.UpgradedState.Unmarshal(rsxSchema.ValueType())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := (&tfprotov6.RawState{JSON: fixture.Expected}).Unmarshal(rsxSchema.ValueType())
+			if err != nil {
+				t.Fatalf("invalid expected state: %s", err)
+			}
+			if !upgraded.Equal(expected) {
+				t.Errorf("%s:\nexpected %s\ngot      %s", fixture.Description, expected, upgraded)
+			}
+		})
+	}
+
+	for version := int64(0); version < loFiTwinRsxSchemaVersion; version++ {
+		if !covered[version] {
+			t.Errorf("no upgrade fixture for schema version %d", version)
+		}
+	}
+}
+
+func TestLoFiTwinRsxUpgradeStateHistory(t *testing.T) {
+	upgraders := (&T9LoFiTwinRsx{}).UpgradeState(context.Background())
+	for version := int64(0); version < loFiTwinRsxSchemaVersion; version++ {
+		upgrader, ok := upgraders[version]
+		if !ok {
+			t.Errorf("no state upgrader for schema version %d", version)
+			continue
+		}
+		if upgrader.PriorSchema == nil || upgrader.PriorSchema.Version != version {
+			t.Errorf("expected the prior schema of version %d to be declared", version)
+		}
+	}
+	if _, ok := upgraders[loFiTwinRsxSchemaVersion]; ok {
+		t.Errorf("unexpected state upgrader for the current schema version")
+	}
+}
@@ -6,13 +6,16 @@ package provider
 import (
 	"context"
 	"net/http"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -31,13 +34,21 @@ type Tensor9Provider struct {
 
 // Tensor9ProviderModel describes the provider data model.
 type Tensor9ProviderModel struct {
-	Endpoint types.String `tfsdk:"endpoint"`
-	ApiKey   types.String `tfsdk:"api_key"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	ApiKey         types.String `tfsdk:"api_key"`
+	BasePath       types.String `tfsdk:"base_path"`
+	GzipThreshold  types.Int64  `tfsdk:"gzip_threshold"`
+	BatchWindowMs  types.Int64  `tfsdk:"batch_window_ms"`
+	ProjectionKeys types.Map    `tfsdk:"projection_keys"`
+	ReactorPlan    types.Bool   `tfsdk:"reactor_plan"`
 }
 
 type Tensor9ProviderData struct {
-	Client *http.Client
-	Model  *Tensor9ProviderModel
+	Client  *http.Client
+	Model   *Tensor9ProviderModel
+	Reactor *ReactorClient
+	// Reads caches the twin rsxs read from the reactor for the duration of a plan or apply.
+	Reads *ReadCache
 }
 
 func (p *Tensor9Provider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -58,6 +69,36 @@ func (p *Tensor9Provider) Schema(ctx context.Context, req provider.SchemaRequest
 				Optional:            false,
 				Required:            true,
 			},
+			"base_path": schema.StringAttribute{
+				MarkdownDescription: "Overrides the base path that the reactor's services are served under (e.g. `/gateway/v2/stack/tf`). " +
+					"When unset, services are resolved through the vctrl's `/.well-known/tensor9.json` discovery document, falling back to `/stack/tf`",
+				Optional: true,
+			},
+			"gzip_threshold": schema.Int64Attribute{
+				MarkdownDescription: "Gzip-compress request bodies larger than this many bytes, and accept gzip-compressed responses from the reactor. " +
+					"Compression is disabled when unset",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"batch_window_ms": schema.Int64Attribute{
+				MarkdownDescription: "How long, in milliseconds, to hold back events for the same projection so that concurrent events are " +
+					"sent to the reactor in a single batch request. Only worthwhile against reactors that offer the `react.batch.v1` service. " +
+					"Batching is disabled when unset",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"projection_keys": schema.MapAttribute{
+				ElementType: types.StringType,
+				MarkdownDescription: "PEM encoded RSA public keys of the appliances to seal `Secret` vars for, by projection id. " +
+					"Keys of projections not listed here are fetched from the reactor",
+				Optional: true,
+			},
+			"reactor_plan": schema.BoolAttribute{
+				MarkdownDescription: "Preview what creating or changing a twin would do inside its appliance by sending the reactor a dry-run " +
+					"`Plan` event. Plans then warn of the changes the reactor predicts, show the outputs it predicts, and replace twins whose " +
+					"changes it can't apply in place. Defaults to false, planning outputs as unknown and every change but moving a twin as an update",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -74,12 +115,22 @@ func (p *Tensor9Provider) Configure(ctx context.Context, req provider.ConfigureR
 	// Configuration values are now available.
 	// if data.Endpoint.IsNull() { /* ... */ }
 
+	batchWindow := time.Duration(data.BatchWindowMs.ValueInt64()) * time.Millisecond
+
 	// Example client configuration for data sources and resources
 	client := http.DefaultClient
+	reactor := NewReactorClient(client, data.Endpoint.ValueString(), data.ApiKey.ValueString(), ReactorClientOpts{
+		BasePath:       data.BasePath.ValueStringPointer(),
+		GzipThreshold:  data.GzipThreshold.ValueInt64Pointer(),
+		BatchWindow:    batchWindow,
+		ProjectionKeys: mapToStringMap(data.ProjectionKeys),
+	})
 	resp.DataSourceData = client
 	resp.ResourceData = &Tensor9ProviderData{
-		Client: client,
-		Model:  &data,
+		Client:  client,
+		Model:   &data,
+		Reactor: reactor,
+		Reads:   NewReadCache(reactor, batchWindow),
 	}
 }
 
@@ -4,10 +4,16 @@
 package provider
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
 )
 
@@ -32,3 +38,75 @@ func testAccPreCheck(t *testing.T) {
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+func TestProviderConfigureProjectionKeys(t *testing.T) {
+	privateKey, publicKeyPem := testProjectionKey(t)
+	// A reactor that publishes no projection keys, so sealing relies on the configured ones.
+	reactor := httptest.NewServer(http.NotFoundHandler())
+	defer reactor.Close()
+
+	p := New("test")()
+	var schemaResp provider.SchemaResponse
+	p.Schema(context.Background(), provider.SchemaRequest{}, &schemaResp)
+	objType := schemaResp.Schema.Type().TerraformType(context.Background()).(tftypes.Object)
+	vals := make(map[string]tftypes.Value)
+	for k, attrType := range objType.AttributeTypes {
+		vals[k] = tftypes.NewValue(attrType, nil)
+	}
+	vals["endpoint"] = tftypes.NewValue(tftypes.String, reactor.URL)
+	vals["api_key"] = tftypes.NewValue(tftypes.String, "deadbeef")
+	vals["projection_keys"] = testStringMap(map[string]string{"local": publicKeyPem})
+
+	var resp provider.ConfigureResponse
+	p.Configure(context.Background(), provider.ConfigureRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objType, vals)},
+	}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	reactorClient := resp.ResourceData.(*Tensor9ProviderData).Reactor
+	if reactorClient.opts.BatchWindow != 0 {
+		t.Errorf("expected batching to be disabled by default, got a window of %s", reactorClient.opts.BatchWindow)
+	}
+	sealed, err := reactorClient.SealSecretVars(context.Background(), "local", map[string]string{"password": "hunter2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	secret := sealed["password"]
+	if opened, err := OpenSecret(privateKey, "local", "password", &secret); err != nil || opened != "hunter2" {
+		t.Errorf("expected hunter2, got %q, %v", opened, err)
+	}
+}
+
+func TestProviderValidateConfigNegativeSettings(t *testing.T) {
+	for _, attr := range []string{"gzip_threshold", "batch_window_ms"} {
+		t.Run(attr, func(t *testing.T) {
+			server := providerserver.NewProtocol6(New("test")())()
+			schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			objType := schemaResp.Provider.ValueType().(tftypes.Object)
+			vals := make(map[string]tftypes.Value)
+			for k, attrType := range objType.AttributeTypes {
+				vals[k] = tftypes.NewValue(attrType, nil)
+			}
+			vals["endpoint"] = tftypes.NewValue(tftypes.String, "http://localhost")
+			vals["api_key"] = tftypes.NewValue(tftypes.String, "deadbeef")
+			vals[attr] = tftypes.NewValue(tftypes.Number, -1)
+			config, err := tfprotov6.NewDynamicValue(objType, tftypes.NewValue(objType, vals))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			resp, err := server.ValidateProviderConfig(context.Background(), &tfprotov6.ValidateProviderConfigRequest{Config: &config})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityError {
+				t.Errorf("expected a single error diagnostic, got %v", resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// TfRsxEvtBatch carries several events for the same projection in one request.
+type TfRsxEvtBatch struct {
+	ApiKey string      `json:"apiKey"`
+	Evts   []*TfRsxEvt `json:"evts"`
+}
+
+// TfRsxEvtBatchResult holds a result per event of a TfRsxEvtBatch, in the same order as the events.
+type TfRsxEvtBatchResult struct {
+	Results []TfRsxEvtBatchItemResult `json:"results"`
+}
+
+// TfRsxEvtBatchItemResult holds either the result of an event or the reason the reactor failed to handle it.
+type TfRsxEvtBatchItemResult struct {
+	Result *TfRsxEvtResult `json:"result"`
+	Error  *string         `json:"error"`
+}
+
+type evtBatch struct {
+	ctx     context.Context
+	evts    []*TfRsxEvt
+	waiters []chan evtBatchReply
+	// The secrets masked by the contexts of the batched events, which are masked while sending the whole batch.
+	secrets map[string]struct{}
+}
+
+type evtBatchReply struct {
+	result *TfRsxEvtResult
+	err    error
+}
+
+// reactBatched holds evt back for the batch window so that it can be sent along with other events for the same
+// projection, then waits for its result.
+func (c *ReactorClient) reactBatched(ctx context.Context, projectionId string, evt *TfRsxEvt) (*TfRsxEvtResult, error) {
+	waiter := make(chan evtBatchReply, 1)
+
+	c.batchesMu.Lock()
+	if c.batches == nil {
+		c.batches = make(map[string]*evtBatch)
+	}
+	batch, ok := c.batches[projectionId]
+	if !ok {
+		// The batch outlives the operation that opened it, so it mustn't be cancelled along with that operation.
+		batch = &evtBatch{ctx: context.WithoutCancel(ctx), secrets: make(map[string]struct{})}
+		c.batches[projectionId] = batch
+		time.AfterFunc(c.opts.BatchWindow, func() {
+			c.flushBatch(projectionId, batch)
+		})
+	}
+	batch.evts = append(batch.evts, evt)
+	batch.waiters = append(batch.waiters, waiter)
+	maps.Copy(batch.secrets, maskedSecrets(ctx))
+	c.batchesMu.Unlock()
+
+	select {
+	case reply := <-waiter:
+		return reply.result, reply.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *ReactorClient) flushBatch(projectionId string, batch *evtBatch) {
+	c.batchesMu.Lock()
+	if c.batches[projectionId] == batch {
+		delete(c.batches, projectionId)
+	}
+	c.batchesMu.Unlock()
+
+	ctx := MaskSecrets(batch.ctx, slices.Collect(maps.Keys(batch.secrets))...)
+
+	if len(batch.evts) > 1 && !c.batchUnavailable.Load() {
+		tflog.Debug(ctx, fmt.Sprintf("Sending batch of %d events for projection %s", len(batch.evts), projectionId))
+
+		var batchResult TfRsxEvtBatchResult
+		err := c.post(ctx, ReactBatchSvc, &TfRsxEvtBatch{ApiKey: c.apiKey, Evts: batch.evts}, &batchResult)
+		switch {
+		case err == nil && len(batchResult.Results) != len(batch.evts):
+			err = fmt.Errorf("reactor returned %d results for a batch of %d events", len(batchResult.Results), len(batch.evts))
+		case err != nil && isSvcUnavailable(err):
+			tflog.Debug(ctx, "Reactor doesn't offer the batch service; sending events one by one")
+			c.batchUnavailable.Store(true)
+		}
+
+		if !c.batchUnavailable.Load() {
+			for i, waiter := range batch.waiters {
+				switch {
+				case err != nil:
+					waiter <- evtBatchReply{err: err}
+				case batchResult.Results[i].Error != nil:
+					waiter <- evtBatchReply{err: fmt.Errorf("reactor failed to handle event: %s", *batchResult.Results[i].Error)}
+				case batchResult.Results[i].Result == nil:
+					waiter <- evtBatchReply{err: fmt.Errorf("reactor returned no result for event")}
+				default:
+					waiter <- evtBatchReply{result: batchResult.Results[i].Result}
+				}
+			}
+			return
+		}
+	}
+
+	// Single event mode, either because there was nothing to coalesce or because batching isn't available.
+	var wg sync.WaitGroup
+	for i, evt := range batch.evts {
+		wg.Add(1)
+		go func(evt *TfRsxEvt, waiter chan evtBatchReply) {
+			defer wg.Done()
+			result, err := c.reactOne(ctx, evt)
+			waiter <- evtBatchReply{result: result, err: err}
+		}(evt, batch.waiters[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReactorClientBatch(t *testing.T) {
+	var batchReqs, singleReqs atomic.Int32
+
+	createdResult := func(evt *TfRsxEvt) *TfRsxEvtResult {
+		infraId := "infra_" + *evt.LoFiTwinRsx.RsxId
+		after := *evt.LoFiTwinRsx
+		after.InfraId = &infraId
+		return &TfRsxEvtResult{
+			EvtType:     evt.EvtType,
+			RsxType:     evt.RsxType,
+			ResultType:  "Created",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: evt.LoFiTwinRsx, After: &after},
+		}
+	}
+
+	newReactor := func(batching bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch {
+			case batching && r.Method == "POST" && r.URL.Path == "/stack/tf/react/batch":
+				batchReqs.Add(1)
+				var batch TfRsxEvtBatch
+				if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+					http.Error(w, "failed to unmarshal batch", http.StatusBadRequest)
+					return
+				}
+				var batchResult TfRsxEvtBatchResult
+				for _, evt := range batch.Evts {
+					if *evt.LoFiTwinRsx.RsxId == "rsx_bad" {
+						reason := "rsx_bad is bad"
+						batchResult.Results = append(batchResult.Results, TfRsxEvtBatchItemResult{Error: &reason})
+						continue
+					}
+					batchResult.Results = append(batchResult.Results, TfRsxEvtBatchItemResult{Result: createdResult(evt)})
+				}
+				_ = json.NewEncoder(w).Encode(batchResult)
+			case r.Method == "POST" && r.URL.Path == "/stack/tf/react":
+				singleReqs.Add(1)
+				var evt TfRsxEvt
+				if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
+					http.Error(w, "failed to unmarshal evt", http.StatusBadRequest)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(createdResult(&evt))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	}
+
+	reactAll := func(client *ReactorClient, rsxIds []string) map[string]error {
+		var mu sync.Mutex
+		errs := make(map[string]error)
+
+		var wg sync.WaitGroup
+		for _, rsxId := range rsxIds {
+			wg.Add(1)
+			go func(rsxId string) {
+				defer wg.Done()
+				projectionId := "0000000000000000:0000000000000000:0000000000000000"
+				result, err := client.React(context.Background(), &TfRsxEvt{
+					RsxType: "LoFiTwin",
+					EvtType: "Create",
+					LoFiTwinRsx: &TfLoFiTwinRsx{
+						RsxId:        &rsxId,
+						ProjectionId: &projectionId,
+					},
+				})
+				if err == nil && *result.LoFiTwinRsx.After.InfraId != "infra_"+rsxId {
+					err = fmt.Errorf("got result for %s", *result.LoFiTwinRsx.After.InfraId)
+				}
+				mu.Lock()
+				errs[rsxId] = err
+				mu.Unlock()
+			}(rsxId)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("coalesced", func(t *testing.T) {
+		batchReqs.Store(0)
+		singleReqs.Store(0)
+		reactor := newReactor(true)
+		defer reactor.Close()
+
+		client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{BatchWindow: 100 * time.Millisecond})
+		errs := reactAll(client, []string{"rsx_a", "rsx_b", "rsx_c", "rsx_bad"})
+
+		for _, rsxId := range []string{"rsx_a", "rsx_b", "rsx_c"} {
+			if errs[rsxId] != nil {
+				t.Errorf("unexpected error for %s: %s", rsxId, errs[rsxId])
+			}
+		}
+		if errs["rsx_bad"] == nil {
+			t.Errorf("expected an error for rsx_bad")
+		}
+		if batchReqs.Load() != 1 || singleReqs.Load() != 0 {
+			t.Errorf("expected 1 batch request, got %d batch and %d single requests", batchReqs.Load(), singleReqs.Load())
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		batchReqs.Store(0)
+		singleReqs.Store(0)
+		reactor := newReactor(false)
+		defer reactor.Close()
+
+		client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{BatchWindow: 100 * time.Millisecond})
+		for rsxId, err := range reactAll(client, []string{"rsx_a", "rsx_b", "rsx_c"}) {
+			if err != nil {
+				t.Errorf("unexpected error for %s: %s", rsxId, err)
+			}
+		}
+		if singleReqs.Load() != 3 {
+			t.Errorf("expected 3 single requests, got %d", singleReqs.Load())
+		}
+	})
+}
@@ -0,0 +1,458 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The discovery document is served relative to the provider endpoint and maps reactor service names to URLs, in the
+// spirit of terraform's own service discovery, e.g.
+//
+//	{ "react.v1": "/gateway/v2/stack/tf/react" }
+//
+// Relative URLs are resolved against the URL of the discovery document itself.
+const reactorDiscoveryPath = "/.well-known/tensor9.json"
+
+// How long discovering the reactor's services may take.
+const reactorDiscoveryTimeout = 30 * time.Second
+
+// The base path that reactor services live under when the vctrl doesn't publish a discovery document.
+const defaultReactorBasePath = "/stack/tf"
+
+const (
+	ReactSvc      = "react.v1"
+	ReactBatchSvc = "react.batch.v1"
+	ReadBulkSvc   = "read.bulk.v1"
+	TemplatesSvc  = "templates.v1"
+	KeysSvc       = "keys.v1"
+)
+
+// Paths of the reactor services relative to the base path, used when the vctrl doesn't publish a discovery document or
+// when the base path is explicitly overridden.
+var defaultReactorSvcPaths = map[string]string{
+	ReactSvc:      "react",
+	ReactBatchSvc: "react/batch",
+	ReadBulkSvc:   "read/bulk",
+	TemplatesSvc:  "templates",
+	KeysSvc:       "keys",
+}
+
+// errReactorSvcUnavailable is returned when the vctrl doesn't offer the requested service.
+var errReactorSvcUnavailable = errors.New("reactor service unavailable")
+
+// ReactorClientOpts tunes how a ReactorClient talks to the reactor.
+type ReactorClientOpts struct {
+	// BasePath overrides the base path of the reactor's services, skipping service discovery.
+	BasePath *string
+	// GzipThreshold enables gzip compression of request bodies larger than this many bytes, and of responses.
+	// Compression is disabled when nil.
+	GzipThreshold *int64
+	// BatchWindow is how long events for the same projection are held back to be coalesced into one batch request.
+	// Batching is disabled when zero.
+	BatchWindow time.Duration
+	// ProjectionKeys holds PEM encoded public keys to seal secrets for projections with, by projection id. Keys for
+	// other projections are fetched from the reactor.
+	ProjectionKeys map[string]string
+}
+
+// ReactorClient talks to the vctrl's terraform stack reactor, resolving every endpoint through service discovery.
+type ReactorClient struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+	opts     ReactorClientOpts
+
+	discoverMu sync.Mutex
+	// The discovered services; nil until discovery succeeds, so that failed discoveries are retried.
+	services map[string]*url.URL
+
+	// Set once the reactor rejects a gzip-encoded request body, after which bodies are sent uncompressed.
+	gzipRejected atomic.Bool
+
+	// Set once the reactor turns out not to offer the templates service, after which templates are sent inline.
+	templatesUnavailable atomic.Bool
+	// Templates known to be stored by the reactor, by sha256; values are *templateUpload.
+	templateUploads sync.Map
+
+	// Set once the reactor turns out not to offer the batch service, after which events are sent one by one.
+	batchUnavailable atomic.Bool
+	batchesMu        sync.Mutex
+	// Batches of events waiting to be sent, by projection id.
+	batches map[string]*evtBatch
+
+	// Set once the reactor turns out not to offer the bulk read service, after which twin rsxs are read one by one.
+	bulkReadUnavailable atomic.Bool
+
+	// Public keys fetched from the reactor, by projection id; values are *ProjectionKey.
+	projectionKeys sync.Map
+}
+
+// reactorStatusError is returned when the reactor responds with a non-2xx status.
+type reactorStatusError struct {
+	Url        string
+	Status     string
+	StatusCode int
+	Body       string
+}
+
+func (e *reactorStatusError) Error() string {
+	return fmt.Sprintf("POST %s returned %s: %s", e.Url, e.Status, e.Body)
+}
+
+// isSvcUnavailable reports whether err means that the vctrl doesn't offer a service, either because discovery didn't
+// list it or because its default endpoint doesn't exist.
+func isSvcUnavailable(err error) bool {
+	var statusErr *reactorStatusError
+	return errors.Is(err, errReactorSvcUnavailable) || (errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound)
+}
+
+type templateUpload struct {
+	once sync.Once
+	err  error
+}
+
+func NewReactorClient(client *http.Client, endpoint string, apiKey string, opts ReactorClientOpts) *ReactorClient {
+	return &ReactorClient{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		apiKey:   apiKey,
+		opts:     opts,
+	}
+}
+
+// maskedSecretsKey is the context key of the set of secrets masked in the log lines written with a context.
+type maskedSecretsKey struct{}
+
+// MaskSecrets masks the given values in the log lines written with the returned context, which includes the requests
+// that the client sends on behalf of that context.
+func MaskSecrets(ctx context.Context, secrets ...string) context.Context {
+	masked := maskedSecrets(ctx)
+	var added []string
+	for _, secret := range secrets {
+		if _, ok := masked[secret]; !ok && secret != "" {
+			added = append(added, secret)
+		}
+	}
+	if len(added) == 0 {
+		return ctx
+	}
+
+	// The set is copied rather than added to, as it's shared with the parent context.
+	result := maps.Clone(masked)
+	if result == nil {
+		result = make(map[string]struct{})
+	}
+	for _, secret := range added {
+		result[secret] = struct{}{}
+	}
+	ctx = context.WithValue(ctx, maskedSecretsKey{}, result)
+	return tflog.MaskLogStrings(ctx, added...)
+}
+
+// maskedSecrets returns the set of secrets masked by MaskSecrets in the log lines written with ctx.
+func maskedSecrets(ctx context.Context) map[string]struct{} {
+	masked, _ := ctx.Value(maskedSecretsKey{}).(map[string]struct{})
+	return masked
+}
+
+// logCtx masks the api key in the log lines written with the returned context.
+func (c *ReactorClient) logCtx(ctx context.Context) context.Context {
+	return tflog.MaskLogStrings(ctx, c.apiKey)
+}
+
+// SvcUrl resolves the URL of the named reactor service.
+func (c *ReactorClient) SvcUrl(ctx context.Context, svc string) (string, error) {
+	services, err := c.discoveredServices(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	svcUrl, ok := services[svc]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errReactorSvcUnavailable, svc)
+	}
+	return svcUrl.String(), nil
+}
+
+// discoveredServices returns the reactor's services, discovering them unless an earlier discovery succeeded. Callers
+// waiting on a discovery share it, so it isn't cancelled along with the request that started it.
+func (c *ReactorClient) discoveredServices(ctx context.Context) (map[string]*url.URL, error) {
+	c.discoverMu.Lock()
+	defer c.discoverMu.Unlock()
+
+	if c.services != nil {
+		return c.services, nil
+	}
+	discoverCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reactorDiscoveryTimeout)
+	defer cancel()
+	services, err := c.discover(discoverCtx)
+	if err != nil {
+		return nil, err
+	}
+	c.services = services
+	return services, nil
+}
+
+func (c *ReactorClient) discover(ctx context.Context) (map[string]*url.URL, error) {
+	if c.opts.BasePath != nil {
+		tflog.Debug(ctx, fmt.Sprintf("Skipping reactor service discovery; base_path=%s", *c.opts.BasePath))
+		return c.defaultServices(*c.opts.BasePath)
+	}
+
+	discoveryUrl := c.endpoint + reactorDiscoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document %s: %w", discoveryUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		tflog.Debug(ctx, fmt.Sprintf("No discovery document at %s; using default reactor services", discoveryUrl))
+		return c.defaultServices(defaultReactorBasePath)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch discovery document %s: status %s", discoveryUrl, resp.Status)
+	}
+
+	var doc map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document %s: %w", discoveryUrl, err)
+	}
+
+	base, err := url.Parse(discoveryUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery url %s: %w", discoveryUrl, err)
+	}
+
+	services := make(map[string]*url.URL)
+	for svc, v := range doc {
+		// Unknown service definitions (e.g. objects) are left for newer providers to interpret.
+		ref, ok := v.(string)
+		if !ok {
+			continue
+		}
+		svcUrl, err := base.Parse(ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url for reactor service %s in discovery document: %w", svc, err)
+		}
+		services[svc] = svcUrl
+		tflog.Debug(ctx, fmt.Sprintf("Discovered reactor service %s at %s", svc, svcUrl))
+	}
+	return services, nil
+}
+
+func (c *ReactorClient) defaultServices(basePath string) (map[string]*url.URL, error) {
+	base := c.endpoint + "/" + strings.Trim(basePath, "/") + "/"
+	if strings.Trim(basePath, "/") == "" {
+		base = c.endpoint + "/"
+	}
+
+	services := make(map[string]*url.URL)
+	for svc, svcPath := range defaultReactorSvcPaths {
+		svcUrl, err := url.Parse(base + svcPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url for reactor service %s: %w", svc, err)
+		}
+		services[svc] = svcUrl
+	}
+	return services, nil
+}
+
+// React sends an event to the reactor and returns its result.
+func (c *ReactorClient) React(ctx context.Context, evt *TfRsxEvt) (*TfRsxEvtResult, error) {
+	evt.ApiKey = c.apiKey
+
+	if evt.LoFiTwinRsx != nil && evt.LoFiTwinRsx.Template != nil {
+		template, err := c.templateRef(ctx, evt.LoFiTwinRsx.Template)
+		if err != nil {
+			return nil, err
+		}
+		twinRsx := *evt.LoFiTwinRsx
+		twinRsx.Template = template
+		evt.LoFiTwinRsx = &twinRsx
+	}
+
+	if c.opts.BatchWindow > 0 && !c.batchUnavailable.Load() && evt.LoFiTwinRsx != nil && evt.LoFiTwinRsx.ProjectionId != nil {
+		return c.reactBatched(ctx, *evt.LoFiTwinRsx.ProjectionId, evt)
+	}
+	return c.reactOne(ctx, evt)
+}
+
+func (c *ReactorClient) reactOne(ctx context.Context, evt *TfRsxEvt) (*TfRsxEvtResult, error) {
+	var evtResult TfRsxEvtResult
+	if err := c.post(ctx, ReactSvc, evt, &evtResult); err != nil {
+		return nil, err
+	}
+	return &evtResult, nil
+}
+
+// templateRef makes sure the reactor stores the template, uploading it only if the reactor reports it missing, and
+// returns a reference to the template by its sha256. Falls back to the inline template if the reactor doesn't offer the
+// templates service.
+func (c *ReactorClient) templateRef(ctx context.Context, template *TfLoFiTemplate) (*TfLoFiTemplate, error) {
+	if c.templatesUnavailable.Load() || template.Raw == "" {
+		return template, nil
+	}
+
+	sha := templateSha256(template.Raw)
+
+	v, _ := c.templateUploads.LoadOrStore(sha, &templateUpload{})
+	upload := v.(*templateUpload)
+	upload.once.Do(func() {
+		// Every event referencing the template waits on this upload, so it isn't cancelled along with the first.
+		upload.err = c.uploadTemplate(context.WithoutCancel(ctx), sha, template)
+	})
+
+	if upload.err != nil {
+		// Forget the failed upload so that the next event referencing the template tries again.
+		c.templateUploads.CompareAndDelete(sha, upload)
+
+		if isSvcUnavailable(upload.err) {
+			tflog.Debug(ctx, "Reactor doesn't offer the templates service; sending templates inline")
+			c.templatesUnavailable.Store(true)
+			return template, nil
+		}
+		return nil, fmt.Errorf("failed to upload template %s: %w", sha, upload.err)
+	}
+
+	return &TfLoFiTemplate{Sha256: sha, Fmt: template.Fmt}, nil
+}
+
+func (c *ReactorClient) uploadTemplate(ctx context.Context, sha string, template *TfLoFiTemplate) error {
+	var stat TfTemplateStat
+	err := c.post(ctx, TemplatesSvc, &TfTemplateReq{ApiKey: c.apiKey, Sha256: sha}, &stat)
+	if err != nil {
+		return err
+	}
+	if stat.Present {
+		tflog.Debug(ctx, fmt.Sprintf("Reactor already has template %s", sha))
+		return nil
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Uploading template %s (%d bytes)", sha, len(template.Raw)))
+	err = c.post(ctx, TemplatesSvc, &TfTemplateReq{ApiKey: c.apiKey, Sha256: sha, Template: template}, &stat)
+	if err != nil {
+		return err
+	}
+	if !stat.Present {
+		return fmt.Errorf("reactor did not store template %s", sha)
+	}
+	return nil
+}
+
+// post sends body as JSON to the named service and decodes the JSON response into result.
+func (c *ReactorClient) post(ctx context.Context, svc string, body any, result any) error {
+	ctx = c.logCtx(ctx)
+
+	svcUrl, err := c.SvcUrl(ctx, svc)
+	if err != nil {
+		return err
+	}
+
+	bodyJson, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	compress := c.opts.GzipThreshold != nil && int64(len(bodyJson)) > *c.opts.GzipThreshold && !c.gzipRejected.Load()
+	resp, err := c.send(ctx, svcUrl, bodyJson, compress)
+	if err != nil {
+		return err
+	}
+	if compress && resp.StatusCode == http.StatusUnsupportedMediaType {
+		// The reactor doesn't accept compressed bodies; stop compressing and resend as is.
+		tflog.Debug(ctx, fmt.Sprintf("%s rejected a gzip-encoded body; disabling request compression", svcUrl))
+		_ = resp.Body.Close()
+		c.gzipRejected.Store(true)
+		resp, err = c.send(ctx, svcUrl, bodyJson, false)
+		if err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody := resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to decompress response: %w", err)
+		}
+		defer gzipReader.Close()
+		respBody = gzipReader
+	}
+
+	respBytes, err := io.ReadAll(respBody)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &reactorStatusError{
+			Url:        svcUrl,
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(respBytes)),
+		}
+	}
+
+	if err := json.Unmarshal(respBytes, result); err != nil {
+		return fmt.Errorf("failed to decode response JSON: %w", err)
+	}
+
+	// The response is logged as decoded, so that any secrets it carries are masked.
+	tflog.Debug(ctx, fmt.Sprintf("%s response: %s", svc, redactedJson(result)))
+	return nil
+}
+
+func (c *ReactorClient) send(ctx context.Context, svcUrl string, bodyJson []byte, compress bool) (*http.Response, error) {
+	reqBody := bodyJson
+	if compress {
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		if _, err := gzipWriter.Write(bodyJson); err != nil {
+			return nil, fmt.Errorf("failed to compress request body: %w", err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress request body: %w", err)
+		}
+		reqBody = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svcUrl, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.opts.GzipThreshold != nil {
+		// Setting Accept-Encoding explicitly means the transport leaves decompression of the response to us.
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	return c.client.Do(req)
+}
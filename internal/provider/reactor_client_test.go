@@ -0,0 +1,304 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestReactorClientSvcUrl(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == reactorDiscoveryPath {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				ReactSvc:   "/gateway/v2/stack/tf/react",
+				"other.v1": map[string]string{"url": "/other"},
+			})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer gateway.Close()
+
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	basePath := "/gateway/v3/"
+
+	cases := []struct {
+		name     string
+		client   *ReactorClient
+		expected string
+	}{
+		{
+			name:     "discovered",
+			client:   NewReactorClient(http.DefaultClient, gateway.URL, "deadbeef", ReactorClientOpts{}),
+			expected: gateway.URL + "/gateway/v2/stack/tf/react",
+		},
+		{
+			name:     "default",
+			client:   NewReactorClient(http.DefaultClient, plain.URL+"/", "deadbeef", ReactorClientOpts{}),
+			expected: plain.URL + "/stack/tf/react",
+		},
+		{
+			name:     "base_path overrides discovery",
+			client:   NewReactorClient(http.DefaultClient, gateway.URL, "deadbeef", ReactorClientOpts{BasePath: &basePath}),
+			expected: gateway.URL + "/gateway/v3/react",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := c.client.SvcUrl(context.Background(), ReactSvc)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+
+	_, err := NewReactorClient(http.DefaultClient, gateway.URL, "deadbeef", ReactorClientOpts{}).SvcUrl(context.Background(), "missing.v1")
+	if !errors.Is(err, errReactorSvcUnavailable) {
+		t.Errorf("expected errReactorSvcUnavailable, got %v", err)
+	}
+}
+
+func TestReactorClientSvcUrlRetry(t *testing.T) {
+	var discoveries int
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != reactorDiscoveryPath {
+			http.NotFound(w, r)
+			return
+		}
+		discoveries++
+		if discoveries == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{ReactSvc: "/gateway/v2/stack/tf/react"})
+	}))
+	defer gateway.Close()
+
+	client := NewReactorClient(http.DefaultClient, gateway.URL, "deadbeef", ReactorClientOpts{})
+	if _, err := client.SvcUrl(context.Background(), ReactSvc); err == nil {
+		t.Fatalf("expected the failed discovery to fail")
+	}
+
+	// A later request discovers again, even if it's cancelled before the discovery completes.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 2; i++ {
+		actual, err := client.SvcUrl(ctx, ReactSvc)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expected := gateway.URL + "/gateway/v2/stack/tf/react"; actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+	if discoveries != 2 {
+		t.Errorf("expected a successful discovery to be kept, got %d discoveries", discoveries)
+	}
+}
+
+func TestReactorClientGzip(t *testing.T) {
+	var compressedReqs, plainReqs int
+
+	reactor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/stack/tf/react" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			compressedReqs++
+			gzipReader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "failed to decompress body", http.StatusBadRequest)
+				return
+			}
+			body = gzipReader
+		} else {
+			plainReqs++
+		}
+
+		var evt TfRsxEvt
+		if err := json.NewDecoder(body).Decode(&evt); err != nil {
+			http.Error(w, "failed to unmarshal evt", http.StatusBadRequest)
+			return
+		}
+
+		evtResult := TfRsxEvtResult{
+			EvtType:    evt.EvtType,
+			RsxType:    evt.RsxType,
+			ResultType: "Created",
+			LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{
+				Before: evt.LoFiTwinRsx,
+				After:  evt.LoFiTwinRsx,
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gzipWriter := gzip.NewWriter(w)
+			defer gzipWriter.Close()
+			_ = json.NewEncoder(gzipWriter).Encode(evtResult)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(evtResult)
+	}))
+	defer reactor.Close()
+
+	threshold := int64(1024)
+	client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{GzipThreshold: &threshold})
+
+	for _, raw := range []string{"{}", "{" + strings.Repeat(" ", 4096) + "}"} {
+		rsxId := "rsx_a"
+		result, err := client.React(context.Background(), &TfRsxEvt{
+			RsxType: "LoFiTwin",
+			EvtType: "Create",
+			LoFiTwinRsx: &TfLoFiTwinRsx{
+				RsxId:    &rsxId,
+				Template: &TfLoFiTemplate{Raw: raw, Fmt: "TerraformJson"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.LoFiTwinRsx.After.Template.Raw != raw {
+			t.Errorf("template did not round trip through the reactor")
+		}
+	}
+
+	if compressedReqs != 1 || plainReqs != 1 {
+		t.Errorf("expected 1 compressed and 1 plain request, got %d compressed and %d plain", compressedReqs, plainReqs)
+	}
+}
+
+func TestReactorClientTemplateUpload(t *testing.T) {
+	templates := make(map[string]string)
+	var uploads int
+
+	reactor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/stack/tf/templates":
+			var req TfTemplateReq
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "failed to unmarshal template req", http.StatusBadRequest)
+				return
+			}
+			if req.Template != nil {
+				uploads++
+				templates[req.Sha256] = req.Template.Raw
+			}
+			_, present := templates[req.Sha256]
+			_ = json.NewEncoder(w).Encode(TfTemplateStat{Sha256: req.Sha256, Present: present})
+		case r.Method == "POST" && r.URL.Path == "/stack/tf/react":
+			var evt TfRsxEvt
+			if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
+				http.Error(w, "failed to unmarshal evt", http.StatusBadRequest)
+				return
+			}
+			template := evt.LoFiTwinRsx.Template
+			if template.Raw != "" {
+				http.Error(w, "expected template by reference", http.StatusBadRequest)
+				return
+			}
+			raw, ok := templates[template.Sha256]
+			if !ok {
+				http.Error(w, "unknown template", http.StatusBadRequest)
+				return
+			}
+			after := *evt.LoFiTwinRsx
+			after.Template = &TfLoFiTemplate{Raw: raw, Fmt: template.Fmt}
+			_ = json.NewEncoder(w).Encode(TfRsxEvtResult{
+				EvtType:     evt.EvtType,
+				RsxType:     evt.RsxType,
+				ResultType:  "Created",
+				LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: evt.LoFiTwinRsx, After: &after},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer reactor.Close()
+
+	client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{})
+
+	raw := `{"resource":{}}`
+	for _, rsxId := range []string{"rsx_a", "rsx_b", "rsx_c"} {
+		result, err := client.React(context.Background(), &TfRsxEvt{
+			RsxType: "LoFiTwin",
+			EvtType: "Create",
+			LoFiTwinRsx: &TfLoFiTwinRsx{
+				RsxId:    &rsxId,
+				Template: &TfLoFiTemplate{Raw: raw, Fmt: "TerraformJson"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.LoFiTwinRsx.After.Template.Raw != raw {
+			t.Errorf("template did not round trip through the reactor")
+		}
+	}
+
+	if uploads != 1 {
+		t.Errorf("expected the template to be uploaded once, got %d uploads", uploads)
+	}
+
+	// Uploads that other events wait on aren't cancelled along with the event that started them.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ref, err := client.templateRef(ctx, &TfLoFiTemplate{Raw: `{"resource":{"a":{}}}`, Fmt: "TerraformJson"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := templates[ref.Sha256]; !ok || uploads != 2 {
+		t.Errorf("expected the template to be uploaded, got %d uploads", uploads)
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	masked := MaskSecrets(ctx, "hunter2", "hunter2", "")
+	if len(maskedSecrets(masked)) != 1 {
+		t.Errorf("expected a single masked secret, got %v", maskedSecrets(masked))
+	}
+	if again := MaskSecrets(masked, "hunter2"); again != masked {
+		t.Errorf("expected masking a masked secret again to leave the context as is")
+	}
+
+	tflog.Info(masked, "password is hunter2")
+	if strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("expected the secret to be masked, got %s", logs.String())
+	}
+
+	logs.Reset()
+	tflog.Info(ctx, "password is hunter2")
+	if !strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("expected the secret to be masked only in the context it was masked for, got %s", logs.String())
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// TfRsxBulkRead asks the reactor for the current state of several twin rsxs at once.
+type TfRsxBulkRead struct {
+	ApiKey   string   `json:"apiKey"`
+	RsxType  string   `json:"rsxType"`
+	InfraIds []string `json:"infraIds"`
+}
+
+// TfRsxBulkReadResult holds the twin rsxs found by a TfRsxBulkRead by infra id; rsxs that no longer exist are absent.
+type TfRsxBulkReadResult struct {
+	LoFiTwinRsxs map[string]*TfLoFiTwinRsx `json:"loFiTwinRsxs"`
+}
+
+// ReadCache coalesces concurrent reads of twin rsxs into bulk queries against the reactor, and remembers their results
+// for the lifetime of the provider (i.e. a single plan or apply) so that each resource's Read picks its entry from the
+// shared result.
+type ReadCache struct {
+	reactor *ReactorClient
+	window  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*readEntry
+	pending []string
+}
+
+type readEntry struct {
+	done chan struct{}
+	rsx  *TfLoFiTwinRsx
+	err  error
+}
+
+func NewReadCache(reactor *ReactorClient, window time.Duration) *ReadCache {
+	return &ReadCache{
+		reactor: reactor,
+		window:  window,
+		entries: make(map[string]*readEntry),
+	}
+}
+
+// Get returns the twin rsx with the given infra id, or nil if the reactor doesn't know it.
+func (c *ReadCache) Get(ctx context.Context, infraId string) (*TfLoFiTwinRsx, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[infraId]
+	if !ok {
+		entry = &readEntry{done: make(chan struct{})}
+		c.entries[infraId] = entry
+		c.pending = append(c.pending, infraId)
+		if len(c.pending) == 1 {
+			// The query outlives the Read that opened it, so it mustn't be cancelled along with that Read.
+			queryCtx := context.WithoutCancel(ctx)
+			time.AfterFunc(c.window, func() {
+				c.flush(queryCtx)
+			})
+		}
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.done:
+		return entry.rsx, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Forget drops the cached entry for the given infra id, e.g. because the twin rsx was just changed.
+func (c *ReadCache) Forget(infraId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[infraId]; ok {
+		select {
+		case <-entry.done:
+			delete(c.entries, infraId)
+		default:
+			// Still in flight; the entry is left for the readers waiting on it.
+		}
+	}
+}
+
+func (c *ReadCache) flush(ctx context.Context) {
+	c.mu.Lock()
+	infraIds := c.pending
+	c.pending = nil
+	entries := make([]*readEntry, len(infraIds))
+	for i, infraId := range infraIds {
+		entries[i] = c.entries[infraId]
+	}
+	c.mu.Unlock()
+
+	rsxs, err := c.reactor.ReadLoFiTwins(ctx, infraIds)
+	for i, infraId := range infraIds {
+		entry := entries[i]
+		if err != nil {
+			entry.err = err
+			// Failed reads aren't cached so that a later Read can try again.
+			c.mu.Lock()
+			if c.entries[infraId] == entry {
+				delete(c.entries, infraId)
+			}
+			c.mu.Unlock()
+		} else {
+			entry.rsx = rsxs[infraId]
+		}
+		close(entry.done)
+	}
+}
+
+// ReadLoFiTwins reads the given twin rsxs with a single bulk query, falling back to a Read event per twin rsx if the
+// reactor doesn't offer bulk reads. Twin rsxs that the reactor doesn't know are absent from the result.
+func (c *ReactorClient) ReadLoFiTwins(ctx context.Context, infraIds []string) (map[string]*TfLoFiTwinRsx, error) {
+	if len(infraIds) > 1 && !c.bulkReadUnavailable.Load() {
+		tflog.Debug(ctx, fmt.Sprintf("Reading %d twin rsxs in bulk", len(infraIds)))
+
+		var bulkResult TfRsxBulkReadResult
+		err := c.post(ctx, ReadBulkSvc, &TfRsxBulkRead{ApiKey: c.apiKey, RsxType: "LoFiTwin", InfraIds: infraIds}, &bulkResult)
+		if err == nil {
+			if bulkResult.LoFiTwinRsxs == nil {
+				bulkResult.LoFiTwinRsxs = make(map[string]*TfLoFiTwinRsx)
+			}
+			return bulkResult.LoFiTwinRsxs, nil
+		}
+		if !isSvcUnavailable(err) {
+			return nil, err
+		}
+		tflog.Debug(ctx, "Reactor doesn't offer the bulk read service; reading twin rsxs one by one")
+		c.bulkReadUnavailable.Store(true)
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	rsxs := make(map[string]*TfLoFiTwinRsx)
+
+	var wg sync.WaitGroup
+	for _, infraId := range infraIds {
+		wg.Add(1)
+		go func(infraId string) {
+			defer wg.Done()
+			rsx, err := c.readLoFiTwin(ctx, infraId)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if rsx != nil {
+				rsxs[infraId] = rsx
+			}
+		}(infraId)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return rsxs, nil
+}
+
+func (c *ReactorClient) readLoFiTwin(ctx context.Context, infraId string) (*TfLoFiTwinRsx, error) {
+	evtResult, err := c.React(ctx, &TfRsxEvt{
+		RsxType: "LoFiTwin",
+		EvtType: "Read",
+		LoFiTwinRsx: &TfLoFiTwinRsx{
+			InfraId: &infraId,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if evtResult.ResultType == "NotFound" || evtResult.LoFiTwinRsx == nil {
+		return nil, nil
+	}
+	return evtResult.LoFiTwinRsx.After, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadCache(t *testing.T) {
+	twins := map[string]*TfLoFiTwinRsx{}
+	for _, infraId := range []string{"infra_a", "infra_b", "infra_c"} {
+		twins[infraId] = &TfLoFiTwinRsx{InfraId: &infraId}
+	}
+
+	var bulkReqs, readEvts atomic.Int32
+
+	newReactor := func(bulk bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch {
+			case bulk && r.Method == "POST" && r.URL.Path == "/stack/tf/read/bulk":
+				bulkReqs.Add(1)
+				var bulkRead TfRsxBulkRead
+				if err := json.NewDecoder(r.Body).Decode(&bulkRead); err != nil {
+					http.Error(w, "failed to unmarshal bulk read", http.StatusBadRequest)
+					return
+				}
+				bulkResult := TfRsxBulkReadResult{LoFiTwinRsxs: map[string]*TfLoFiTwinRsx{}}
+				for _, infraId := range bulkRead.InfraIds {
+					if twin, ok := twins[infraId]; ok {
+						bulkResult.LoFiTwinRsxs[infraId] = twin
+					}
+				}
+				_ = json.NewEncoder(w).Encode(bulkResult)
+			case r.Method == "POST" && r.URL.Path == "/stack/tf/react":
+				readEvts.Add(1)
+				var evt TfRsxEvt
+				if err := json.NewDecoder(r.Body).Decode(&evt); err != nil || evt.EvtType != "Read" {
+					http.Error(w, "expected a Read evt", http.StatusBadRequest)
+					return
+				}
+				twin, ok := twins[*evt.LoFiTwinRsx.InfraId]
+				if !ok {
+					_ = json.NewEncoder(w).Encode(TfRsxEvtResult{EvtType: evt.EvtType, RsxType: evt.RsxType, ResultType: "NotFound"})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(TfRsxEvtResult{
+					EvtType:     evt.EvtType,
+					RsxType:     evt.RsxType,
+					ResultType:  "Read",
+					LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: twin, After: twin},
+				})
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	}
+
+	readAll := func(t *testing.T, cache *ReadCache) {
+		var wg sync.WaitGroup
+		for _, infraId := range []string{"infra_a", "infra_b", "infra_c", "infra_gone", "infra_a"} {
+			wg.Add(1)
+			go func(infraId string) {
+				defer wg.Done()
+				rsx, err := cache.Get(context.Background(), infraId)
+				if err != nil {
+					t.Errorf("unexpected error for %s: %s", infraId, err)
+					return
+				}
+				if _, exists := twins[infraId]; exists != (rsx != nil) {
+					t.Errorf("expected %s to exist=%t, got %v", infraId, exists, rsx)
+				}
+				if rsx != nil && *rsx.InfraId != infraId {
+					t.Errorf("expected %s, got %s", infraId, *rsx.InfraId)
+				}
+			}(infraId)
+		}
+		wg.Wait()
+	}
+
+	t.Run("bulk", func(t *testing.T) {
+		bulkReqs.Store(0)
+		readEvts.Store(0)
+		reactor := newReactor(true)
+		defer reactor.Close()
+
+		client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{})
+		cache := NewReadCache(client, 100*time.Millisecond)
+		readAll(t, cache)
+		// Served from the cache.
+		readAll(t, cache)
+
+		if bulkReqs.Load() != 1 || readEvts.Load() != 0 {
+			t.Errorf("expected 1 bulk read, got %d bulk reads and %d Read evts", bulkReqs.Load(), readEvts.Load())
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		bulkReqs.Store(0)
+		readEvts.Store(0)
+		reactor := newReactor(false)
+		defer reactor.Close()
+
+		client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{})
+		readAll(t, NewReadCache(client, 100*time.Millisecond))
+
+		if readEvts.Load() != 4 {
+			t.Errorf("expected 4 Read evts, got %d", readEvts.Load())
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// resultDiagnostics turns the warnings and notices that the reactor reports with the result of an event into warning
+// diagnostics, since terraform has no diagnostics of a lesser severity.
+func resultDiagnostics(evtResult *TfRsxEvtResult) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, warning := range evtResult.Warnings {
+		diags.AddWarning("Reactor Warning", warning)
+	}
+	for _, notice := range evtResult.Notices {
+		diags.AddWarning("Reactor Notice", notice)
+	}
+	return diags
+}
+
+// appliedResultDiagnostics logs what applying an event changed about a twin rsx, and returns the warnings and notices
+// of the result.
+func appliedResultDiagnostics(ctx context.Context, evtResult *TfRsxEvtResult, rsxId string, props TfRsxProps) diag.Diagnostics {
+	logAppliedChanges(ctx, evtResult, rsxId, props)
+	return resultDiagnostics(evtResult)
+}
+
+// updatedResultDiagnostics is appliedResultDiagnostics for Update events, which also returns what the update changed as
+// a warning diagnostic. Terraform's own plan only shows the vars that were configured, not how the reactor's outputs
+// changed with them, so the changes are shown in terraform's apply output.
+func updatedResultDiagnostics(ctx context.Context, evtResult *TfRsxEvtResult, rsxId string, props TfRsxProps) diag.Diagnostics {
+	diags := resultDiagnostics(evtResult)
+	if diff := logAppliedChanges(ctx, evtResult, rsxId, props); diff != "" {
+		diags.AddWarning(
+			"Applied Twin Changes",
+			fmt.Sprintf("The reactor applied the %s of rsx %s, which changed:\n\n%s", evtResult.EvtType, rsxId, diff),
+		)
+	}
+	return diags
+}
+
+func logAppliedChanges(ctx context.Context, evtResult *TfRsxEvtResult, rsxId string, props TfRsxProps) string {
+	diff := deltaDiff(evtResult.LoFiTwinRsx, props)
+	if diff != "" {
+		tflog.Info(ctx, fmt.Sprintf("%s of rsx %s changed:\n%s", evtResult.EvtType, rsxId, diff))
+	}
+	return diff
+}
+
+// deltaDiff summarizes how the vars and outputs of a twin rsx changed from before an event to after it, one line per
+// changed var or output in the style of terraform's own plans, or returns "" if none changed. The values of secret
+// properties are never shown.
+func deltaDiff(delta *Delta[TfLoFiTwinRsx], props TfRsxProps) string {
+	if delta == nil {
+		return ""
+	}
+	var before, after TfLoFiTwinRsx
+	if delta.Before != nil {
+		before = *delta.Before
+	}
+	if delta.After != nil {
+		after = *delta.After
+	}
+
+	lines := mapDiff("vars", before.Vars, after.Vars, props)
+	lines = append(lines, mapDiff("outputs", before.Outputs, after.Outputs, props)...)
+	return strings.Join(lines, "\n")
+}
+
+func mapDiff(name string, before, after *map[string]string, props TfRsxProps) []string {
+	var beforeMap, afterMap map[string]string
+	if before != nil {
+		beforeMap = *before
+	}
+	if after != nil {
+		afterMap = *after
+	}
+
+	show := func(k, v string) string {
+		if props.IsSecret(k) {
+			return "(sensitive value)"
+		}
+		return fmt.Sprintf("%q", v)
+	}
+
+	keys := slices.Sorted(maps.Keys(beforeMap))
+	for k := range afterMap {
+		if _, ok := beforeMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var lines []string
+	for _, k := range keys {
+		beforeValue, inBefore := beforeMap[k]
+		afterValue, inAfter := afterMap[k]
+		switch {
+		case !inBefore:
+			lines = append(lines, fmt.Sprintf("  + %s[%q] = %s", name, k, show(k, afterValue)))
+		case !inAfter:
+			lines = append(lines, fmt.Sprintf("  - %s[%q] = %s", name, k, show(k, beforeValue)))
+		case beforeValue != afterValue:
+			lines = append(lines, fmt.Sprintf("  ~ %s[%q] = %s -> %s", name, k, show(k, beforeValue), show(k, afterValue)))
+		}
+	}
+	return lines
+}
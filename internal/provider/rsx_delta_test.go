@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDeltaDiff(t *testing.T) {
+	props := propsOfTypes(map[string]TfRsxPropType{"size": I32, "password": Secret}, nil)
+	delta := &Delta[TfLoFiTwinRsx]{
+		Before: &TfLoFiTwinRsx{
+			Vars:    &map[string]string{"size": "1", "name": "a"},
+			Outputs: &map[string]string{"url": "http://a", "password": "hunter2", "stale": "x"},
+		},
+		After: &TfLoFiTwinRsx{
+			Vars:    &map[string]string{"size": "2", "name": "a"},
+			Outputs: &map[string]string{"url": "http://a", "password": "hunter3", "id": "i-1"},
+		},
+	}
+	expected := `  ~ vars["size"] = "1" -> "2"
+  + outputs["id"] = "i-1"
+  ~ outputs["password"] = (sensitive value) -> (sensitive value)
+  - outputs["stale"] = "x"`
+	if diff := deltaDiff(delta, props); diff != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, diff)
+	}
+
+	created := &Delta[TfLoFiTwinRsx]{After: &TfLoFiTwinRsx{Outputs: &map[string]string{"id": "i-1"}}}
+	if diff := deltaDiff(created, props); diff != `  + outputs["id"] = "i-1"` {
+		t.Errorf("expected the outputs of a created twin rsx to be added, got:\n%s", diff)
+	}
+	if diff := deltaDiff(&Delta[TfLoFiTwinRsx]{Before: delta.After, After: delta.After}, props); diff != "" {
+		t.Errorf("expected no diff for an unchanged twin rsx, got:\n%s", diff)
+	}
+}
+
+func TestAppliedResultDiagnostics(t *testing.T) {
+	evtResult := &TfRsxEvtResult{
+		EvtType:  "Update",
+		Warnings: []string{"The template uses a deprecated resource."},
+		Notices:  []string{"Deployed release_2."},
+		LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{
+			Before: &TfLoFiTwinRsx{Vars: &map[string]string{"size": "1"}},
+			After:  &TfLoFiTwinRsx{Vars: &map[string]string{"size": "2"}},
+		},
+	}
+
+	diags := updatedResultDiagnostics(context.Background(), evtResult, "rsx_a", nil)
+	if len(diags) != 3 || diags.WarningsCount() != 3 {
+		t.Fatalf("expected 3 warnings, got %v", diags)
+	}
+	for i, summary := range []string{"Reactor Warning", "Reactor Notice", "Applied Twin Changes"} {
+		if diags[i].Summary() != summary {
+			t.Errorf("expected warning %d to be a %q, got %v", i, summary, diags[i])
+		}
+	}
+	if !strings.Contains(diags[2].Detail(), `~ vars["size"] = "1" -> "2"`) {
+		t.Errorf("expected the diff in the detail, got %q", diags[2].Detail())
+	}
+
+	// Creates and deletes only log what changed.
+	for _, evtType := range []string{"Create", "Delete"} {
+		evtResult.EvtType = evtType
+		diags := appliedResultDiagnostics(context.Background(), evtResult, "rsx_a", nil)
+		if len(diags) != 2 {
+			t.Errorf("expected only the reactor's warning and notice for a %s, got %v", evtType, diags)
+		}
+	}
+}
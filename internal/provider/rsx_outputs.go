@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// splitSecretOutputs splits the outputs the reactor reports into those the schema declares as Secret or sensitive and
+// the rest, so that secret outputs only ever land in the sensitive secret_outputs attribute.
+func splitSecretOutputs(outputs map[string]string, props TfRsxProps) (map[string]string, map[string]string) {
+	plain := make(map[string]string)
+	secret := make(map[string]string)
+	for k, v := range outputs {
+		if props.IsSecret(k) {
+			secret[k] = v
+		} else {
+			plain[k] = v
+		}
+	}
+	return plain, secret
+}
+
+// typedOutputs decodes each output according to the property type the schema declares for it, e.g. I32 outputs into
+// numbers and List<Str> outputs into lists of strings. Undeclared outputs, and outputs that don't decode as their
+// declared type, are kept as strings.
+func typedOutputs(outputs map[string]string, props TfRsxProps) types.Dynamic {
+	attrTypes := make(map[string]attr.Type)
+	attrs := make(map[string]attr.Value)
+	for k, s := range outputs {
+		attrs[k] = types.StringValue(s)
+		if prop, ok := props[k]; ok && prop.Type.IsValid() {
+			if goVal, err := prop.Type.DecodeValue(s); err == nil {
+				attrs[k] = goToAttr(goVal)
+			}
+		}
+		attrTypes[k] = attrs[k].Type(context.Background())
+	}
+	return types.DynamicValue(types.ObjectValueMust(attrTypes, attrs))
+}
+
+// outputsAffected reports whether the planned twin rsx differs from its prior state in anything that affects its
+// outputs: its template, vars, secret vars, schema other than its descriptions and validations, where it's deployed,
+// or the release it's pinned to. Inputs that aren't known yet are assumed to change. The plan is expected to have been
+// through the attribute plan modifiers already, so that template_hash reflects the configured template_file.
+func outputsAffected(ctx context.Context, config tfsdk.Config, plan tfsdk.Plan, state tfsdk.State) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var planned, prior T9LoFiTwinRsxModel
+	var releaseId types.String
+	var secretVars types.Map
+	diags.Append(plan.Get(ctx, &planned)...)
+	diags.Append(state.Get(ctx, &prior)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("secret_vars"), &secretVars)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("release_id"), &releaseId)...)
+	if diags.HasError() {
+		return true, diags
+	}
+
+	changed := func(planned, prior attr.Value) bool {
+		return planned.IsUnknown() || !planned.Equal(prior)
+	}
+	if changed(planned.ProjectionId, prior.ProjectionId) || changed(planned.RsxId, prior.RsxId) ||
+		templateChanged(planned, prior) {
+		return true, diags
+	}
+	// Pinning the twin rsx to another release upgrades it; unpinning it keeps the release it's on.
+	if !releaseId.IsNull() && changed(releaseId, prior.ReleaseId) {
+		return true, diags
+	}
+	if changed(plannedSecretVarsHash(secretVars, prior.SecretHash), prior.SecretHash) {
+		return true, diags
+	}
+
+	if planned.Schema.IsUnknown() {
+		return true, diags
+	}
+	props := schematize(planned.Schema)
+	if !reflect.DeepEqual(props.deployed(), schematize(prior.Schema).deployed()) {
+		return true, diags
+	}
+	plannedVars, d := encodeVars(planned.Vars, props)
+	if d.HasError() {
+		return true, diags
+	}
+	priorVars, d := encodeVars(prior.Vars, props)
+	if d.HasError() {
+		return true, diags
+	}
+	return !reflect.DeepEqual(plannedVars, priorVars), diags
+}
+
+// templateChanged reports whether the planned template of a twin rsx, or the hash of its template_file, or its format
+// differ from its prior state. Reformatting the template doesn't change it.
+func templateChanged(planned, prior T9LoFiTwinRsxModel) bool {
+	switch {
+	case planned.TemplateFmt.IsUnknown() || !planned.TemplateFmt.Equal(prior.TemplateFmt):
+		return true
+	case planned.Template.IsUnknown() || planned.Template.IsNull() != prior.Template.IsNull():
+		return true
+	case !planned.Template.IsNull() && !templatesEquivalent(TfTemplateFmt(planned.TemplateFmt.ValueString()), planned.Template.ValueString(), prior.Template.ValueString()):
+		return true
+	}
+	return planned.TemplateHash.IsUnknown() || !planned.TemplateHash.Equal(prior.TemplateHash)
+}
+
+// planOutputs plans the outputs that the reactor computes when it deploys a twin rsx, keeping their prior values only
+// when affected reports that nothing that affects them changes, so that resources depending on them don't plan against
+// stale values. Unless it's pinned, release_id is planned likewise, since the reactor may upgrade the twin rsx to
+// another release whenever it deploys it anew.
+func planOutputs(ctx context.Context, plan *tfsdk.Plan, config tfsdk.Config, prior T9LoFiTwinRsxModel, affected bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var releaseId types.String
+	diags.Append(config.GetAttribute(ctx, path.Root("release_id"), &releaseId)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	outputs, typed, secretOutputs, plannedReleaseId := prior.Outputs, prior.TypedOutputs, prior.SecretOutputs, prior.ReleaseId
+	if affected {
+		outputs, typed, secretOutputs = types.MapUnknown(types.StringType), types.DynamicUnknown(), types.MapUnknown(types.StringType)
+		plannedReleaseId = types.StringUnknown()
+	}
+	diags.Append(plan.SetAttribute(ctx, path.Root("outputs"), outputs)...)
+	diags.Append(plan.SetAttribute(ctx, path.Root("typed_outputs"), typed)...)
+	diags.Append(plan.SetAttribute(ctx, path.Root("secret_outputs"), secretOutputs)...)
+	// Pinned releases are planned as configured.
+	if releaseId.IsNull() {
+		diags.Append(plan.SetAttribute(ctx, path.Root("release_id"), plannedReleaseId)...)
+	}
+	return diags
+}
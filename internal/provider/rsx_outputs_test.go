@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"maps"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTypedOutputs(t *testing.T) {
+	props := propsOfTypes(map[string]TfRsxPropType{
+		"port":     I32,
+		"enabled":  Bool,
+		"zones":    ListOf(Str),
+		"broken":   I32,
+		"password": Secret,
+	}, nil)
+	outputs, secretOutputs := splitSecretOutputs(map[string]string{
+		"port":       "8080",
+		"enabled":    "true",
+		"zones":      `["a","b"]`,
+		"broken":     "abc",
+		"undeclared": "42",
+		"password":   "hunter2",
+	}, props)
+
+	if len(secretOutputs) != 1 || secretOutputs["password"] != "hunter2" {
+		t.Errorf("expected only password in secret outputs, got %v", secretOutputs)
+	}
+	if _, ok := outputs["password"]; ok {
+		t.Errorf("expected password to be stripped from outputs, got %v", outputs)
+	}
+
+	zones := basetypes.NewTupleValueMust(
+		[]attr.Type{types.StringType, types.StringType},
+		[]attr.Value{types.StringValue("a"), types.StringValue("b")},
+	)
+	expected := types.ObjectValueMust(
+		map[string]attr.Type{
+			"port":       types.NumberType,
+			"enabled":    types.BoolType,
+			"zones":      zones.Type(context.Background()),
+			"broken":     types.StringType,
+			"undeclared": types.StringType,
+		},
+		map[string]attr.Value{
+			"port":       types.NumberValue(big.NewFloat(8080)),
+			"enabled":    types.BoolValue(true),
+			"zones":      zones,
+			"broken":     types.StringValue("abc"),
+			"undeclared": types.StringValue("42"),
+		},
+	)
+	if typed := typedOutputs(outputs, props); !typed.UnderlyingValue().Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, typed)
+	}
+}
+
+// testLoFiTwinPlanning builds the config, plan and prior state of a twin rsx whose prior state was applied from the
+// base config, and whose config is the base config with the given changes. Like terraform, the plan is the config
+// with release_id unknown unless it's configured, and without the write-only secret_vars.
+func testLoFiTwinPlanning(t *testing.T, changes map[string]tftypes.Value) (tfsdk.Config, tfsdk.Plan, tfsdk.State) {
+	t.Helper()
+
+	base := map[string]tftypes.Value{
+		"template":      tftypes.NewValue(tftypes.String, `{"resource": {}}`),
+		"template_fmt":  tftypes.NewValue(tftypes.String, "TerraformJson"),
+		"projection_id": tftypes.NewValue(tftypes.String, "projection_a"),
+		"rsx_id":        tftypes.NewValue(tftypes.String, "rsx_a"),
+		"vars":          testSizeVars("1"),
+		"schema":        testSchema(map[string]TfRsxPropType{"size": I32, "password": Secret}),
+	}
+
+	priorAttrs := maps.Clone(base)
+	priorAttrs["release_id"] = tftypes.NewValue(tftypes.String, "release_1")
+	priorAttrs["secret_vars_hash"] = tftypes.NewValue(tftypes.String,
+		secretVarsHash(map[string]string{"password": "hunter2"}, []byte("0123456789abcdef")))
+	prior := testLoFiTwinConfig(t, priorAttrs)
+
+	configAttrs := maps.Clone(base)
+	configAttrs["secret_vars"] = testStringMap(map[string]string{"password": "hunter2"})
+	maps.Copy(configAttrs, changes)
+	config := testLoFiTwinConfig(t, configAttrs)
+
+	planAttrs := maps.Clone(configAttrs)
+	delete(planAttrs, "secret_vars")
+	if _, ok := changes["release_id"]; !ok {
+		planAttrs["release_id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	plan := testLoFiTwinConfig(t, planAttrs)
+
+	return config, tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}, tfsdk.State{Schema: prior.Schema, Raw: prior.Raw}
+}
+
+// testSizeVars builds the vars of the twin rsx of testLoFiTwinPlanning.
+func testSizeVars(size string) tftypes.Value {
+	return tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"size": tftypes.String}},
+		map[string]tftypes.Value{"size": tftypes.NewValue(tftypes.String, size)})
+}
+
+func TestOutputsAffected(t *testing.T) {
+	documented, diags := propsValue(TfRsxProps{
+		"size":     {Type: I32, Description: "The size", Validation: &TfRsxPropValidation{Min: ptr(1.0)}},
+		"password": {Type: Secret, Description: "The password"},
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	documentedSchema, err := documented.ToTerraformValue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		changes  map[string]tftypes.Value
+		affected bool
+	}{
+		{
+			name: "nothing changed",
+		},
+		{
+			name:     "vars changed",
+			changes:  map[string]tftypes.Value{"vars": testSizeVars("2")},
+			affected: true,
+		},
+		{
+			name:     "vars unknown",
+			changes:  map[string]tftypes.Value{"vars": tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue)},
+			affected: true,
+		},
+		{
+			name:    "schema descriptions and validations changed",
+			changes: map[string]tftypes.Value{"schema": documentedSchema},
+		},
+		{
+			name:     "schema types changed",
+			changes:  map[string]tftypes.Value{"schema": testSchema(map[string]TfRsxPropType{"size": I64, "password": Secret})},
+			affected: true,
+		},
+		{
+			name:     "secret vars changed",
+			changes:  map[string]tftypes.Value{"secret_vars": testStringMap(map[string]string{"password": "hunter3"})},
+			affected: true,
+		},
+		{
+			name:    "template reformatted",
+			changes: map[string]tftypes.Value{"template": tftypes.NewValue(tftypes.String, "{\n  \"resource\": {}\n}\n")},
+		},
+		{
+			name:     "template changed",
+			changes:  map[string]tftypes.Value{"template": tftypes.NewValue(tftypes.String, `{"resource": {"a": {}}}`)},
+			affected: true,
+		},
+		{
+			name:     "template file changed",
+			changes:  map[string]tftypes.Value{"template_hash": tftypes.NewValue(tftypes.String, templateSha256(`{"resource": {}}`))},
+			affected: true,
+		},
+		{
+			name:     "pinned to another release",
+			changes:  map[string]tftypes.Value{"release_id": tftypes.NewValue(tftypes.String, "release_2")},
+			affected: true,
+		},
+		{
+			name:    "pinned to the current release",
+			changes: map[string]tftypes.Value{"release_id": tftypes.NewValue(tftypes.String, "release_1")},
+		},
+		{
+			name:    "unpinned",
+			changes: map[string]tftypes.Value{"release_id": tftypes.NewValue(tftypes.String, nil)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config, plan, state := testLoFiTwinPlanning(t, c.changes)
+			affected, diags := outputsAffected(context.Background(), config, plan, state)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if affected != c.affected {
+				t.Errorf("expected affected=%t, got %t", c.affected, affected)
+			}
+		})
+	}
+}
+
+func TestPlanOutputs(t *testing.T) {
+	cases := []struct {
+		name      string
+		changes   map[string]tftypes.Value
+		outputs   types.Map
+		releaseId types.String
+	}{
+		{
+			name:      "pinned",
+			changes:   map[string]tftypes.Value{"release_id": tftypes.NewValue(tftypes.String, "release_2")},
+			outputs:   types.MapUnknown(types.StringType),
+			releaseId: types.StringValue("release_2"),
+		},
+		{
+			name:      "outputs unaffected",
+			outputs:   types.MapNull(types.StringType),
+			releaseId: types.StringValue("release_1"),
+		},
+		{
+			name: "outputs affected",
+			changes: map[string]tftypes.Value{
+				"vars": testSizeVars("2"),
+			},
+			outputs:   types.MapUnknown(types.StringType),
+			releaseId: types.StringUnknown(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			config, plan, state := testLoFiTwinPlanning(t, c.changes)
+
+			var prior T9LoFiTwinRsxModel
+			diags := state.Get(ctx, &prior)
+			affected, d := outputsAffected(ctx, config, plan, state)
+			diags.Append(d...)
+			diags.Append(planOutputs(ctx, &plan, config, prior, affected)...)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			var planned T9LoFiTwinRsxModel
+			if diags := plan.Get(ctx, &planned); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if !planned.Outputs.Equal(c.outputs) {
+				t.Errorf("expected outputs %s, got %s", c.outputs, planned.Outputs)
+			}
+			if !planned.ReleaseId.Equal(c.releaseId) {
+				t.Errorf("expected release_id %s, got %s", c.releaseId, planned.ReleaseId)
+			}
+		})
+	}
+}
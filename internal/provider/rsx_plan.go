@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TfRsxPlan is the reactor's prediction of how it would apply a Plan event, which it only simulates.
+type TfRsxPlan struct {
+	// Replace is set when the changes can't be applied in place, so that the twin rsx must be deleted and created anew.
+	Replace bool `json:"replace"`
+	// Actions are what the appliance would do to each of the resources underlying the twin rsx.
+	Actions []TfRsxPlannedAction `json:"actions,omitempty"`
+	// Outputs are the outputs that the twin rsx would publish, by name. Outputs whose values aren't known until the
+	// changes are applied are null. Reactors that can't predict which outputs the twin rsx publishes leave this unset;
+	// those that do must publish exactly the predicted outputs, or terraform fails the apply.
+	Outputs *map[string]*string `json:"outputs,omitempty"`
+}
+
+// TfRsxPlannedAction is what the appliance would do to one of the resources underlying a twin rsx.
+type TfRsxPlannedAction struct {
+	// Address is the address of the resource inside the appliance, e.g. aws_s3_bucket.logs.
+	Address string `json:"address"`
+	// Action is one of Create, Update, Replace, Delete or NoOp.
+	Action string  `json:"action"`
+	Reason *string `json:"reason,omitempty"`
+}
+
+// The symbols that terraform marks each kind of change with in plans.
+var plannedActionSymbols = map[string]string{
+	"Create":  "+",
+	"Update":  "~",
+	"Replace": "-/+",
+	"Delete":  "-",
+}
+
+// plannedActionsDetail describes the actions that change resources inside the appliance, one per line in the style of
+// terraform's own plans, or returns "" if none of them do.
+func plannedActionsDetail(actions []TfRsxPlannedAction) string {
+	var lines []string
+	for _, action := range actions {
+		if action.Action == "NoOp" {
+			continue
+		}
+		symbol, ok := plannedActionSymbols[action.Action]
+		if !ok {
+			// Actions that newer reactors may predict.
+			symbol = "?"
+		}
+		line := fmt.Sprintf("  %3s %s (%s)", symbol, action.Address, strings.ToLower(action.Action))
+		if action.Reason != nil && *action.Reason != "" {
+			line += ": " + *action.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setPlannedOutputs plans outputs, secret_outputs and typed_outputs from the outputs that the reactor predicts a twin
+// rsx to publish. Outputs that won't be known until applied are planned unknown, as is typed_outputs unless every
+// output it decodes is known.
+func setPlannedOutputs(ctx context.Context, plan *tfsdk.Plan, outputs map[string]*string, props TfRsxProps) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	plain := make(map[string]attr.Value)
+	secret := make(map[string]attr.Value)
+	knownPlain := make(map[string]string)
+	for _, k := range slices.Sorted(maps.Keys(outputs)) {
+		v := types.StringUnknown()
+		if outputs[k] != nil {
+			v = types.StringValue(*outputs[k])
+		}
+		switch {
+		case props.IsSecret(k):
+			secret[k] = v
+		case outputs[k] != nil:
+			plain[k] = v
+			knownPlain[k] = *outputs[k]
+		default:
+			plain[k] = v
+		}
+	}
+
+	outputsMap, d := types.MapValue(types.StringType, plain)
+	diags.Append(d...)
+	secretOutputsMap, d := types.MapValue(types.StringType, secret)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+	diags.Append(plan.SetAttribute(ctx, path.Root("outputs"), outputsMap)...)
+	diags.Append(plan.SetAttribute(ctx, path.Root("secret_outputs"), secretOutputsMap)...)
+	if len(knownPlain) == len(plain) {
+		diags.Append(plan.SetAttribute(ctx, path.Root("typed_outputs"), typedOutputs(knownPlain, props))...)
+	}
+	return diags
+}
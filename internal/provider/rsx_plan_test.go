@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestPlannedActionsDetail(t *testing.T) {
+	actions := []TfRsxPlannedAction{
+		{Address: "aws_s3_bucket.logs", Action: "Create"},
+		{Address: "aws_iam_role.app", Action: "NoOp"},
+		{Address: "aws_instance.app", Action: "Replace", Reason: ptr("ami changed")},
+		{Address: "aws_sqs_queue.jobs", Action: "Import"},
+	}
+	expected := "    + aws_s3_bucket.logs (create)\n" +
+		"  -/+ aws_instance.app (replace): ami changed\n" +
+		"    ? aws_sqs_queue.jobs (import)"
+	if detail := plannedActionsDetail(actions); detail != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, detail)
+	}
+
+	if detail := plannedActionsDetail(actions[1:2]); detail != "" {
+		t.Errorf("expected no detail for no-op actions, got %q", detail)
+	}
+}
+
+func TestSetPlannedOutputs(t *testing.T) {
+	ctx := context.Background()
+	props := propsOfTypes(map[string]TfRsxPropType{"port": I32, "password": Secret}, nil)
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{})
+	newPlan := func() tfsdk.Plan {
+		return tfsdk.Plan{Schema: config.Schema, Raw: config.Raw.Copy()}
+	}
+
+	plan := newPlan()
+	diags := setPlannedOutputs(ctx, &plan, map[string]*string{"port": ptr("8080"), "password": ptr("hunter2"), "url": nil}, props)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	var rsxModel T9LoFiTwinRsxModel
+	if diags := plan.Get(ctx, &rsxModel); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	expectedOutputs := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"port": types.StringValue("8080"),
+		"url":  types.StringUnknown(),
+	})
+	if !rsxModel.Outputs.Equal(expectedOutputs) {
+		t.Errorf("expected outputs %s, got %s", expectedOutputs, rsxModel.Outputs)
+	}
+	if password := rsxModel.SecretOutputs.Elements()["password"]; !password.Equal(types.StringValue("hunter2")) {
+		t.Errorf("expected the password in secret outputs, got %s", rsxModel.SecretOutputs)
+	}
+	if !rsxModel.TypedOutputs.IsNull() {
+		t.Errorf("expected typed outputs to be left as planned while url is unknown, got %s", rsxModel.TypedOutputs)
+	}
+
+	plan = newPlan()
+	diags = setPlannedOutputs(ctx, &plan, map[string]*string{"port": ptr("8080")}, props)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	var typed types.Dynamic
+	if diags := plan.GetAttribute(ctx, path.Root("typed_outputs"), &typed); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if expected := typedOutputs(map[string]string{"port": "8080"}, props); !typed.Equal(expected) {
+		t.Errorf("expected typed outputs %s, got %s", expected, typed)
+	}
+}
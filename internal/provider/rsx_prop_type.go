@@ -0,0 +1,605 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type TfRsxPropType string
+
+const (
+	Bool      TfRsxPropType = "Bool"
+	I32       TfRsxPropType = "I32"
+	I64       TfRsxPropType = "I64"
+	F32       TfRsxPropType = "F32"
+	F64       TfRsxPropType = "F64"
+	Str       TfRsxPropType = "Str"
+	Secret    TfRsxPropType = "Secret"
+	Json      TfRsxPropType = "Json"
+	Bytes     TfRsxPropType = "Bytes"
+	Timestamp TfRsxPropType = "Timestamp"
+	Duration  TfRsxPropType = "Duration"
+)
+
+// Container property types are written List<T> and Map<T>, for any property type T other than Secret.
+const (
+	listPropType = "List"
+	mapPropType  = "Map"
+)
+
+func ListOf(elem TfRsxPropType) TfRsxPropType {
+	return TfRsxPropType(listPropType + "<" + string(elem) + ">")
+}
+
+func MapOf(elem TfRsxPropType) TfRsxPropType {
+	return TfRsxPropType(mapPropType + "<" + string(elem) + ">")
+}
+
+// TfRsxPropTypes lists every TfRsxPropType other than the containers, in the order they're documented.
+var TfRsxPropTypes = []TfRsxPropType{Bool, I32, I64, F32, F64, Str, Secret, Json, Bytes, Timestamp, Duration}
+
+// container splits a List<T> or Map<T> property type into its container and element type.
+func (t TfRsxPropType) container() (string, TfRsxPropType, bool) {
+	s := string(t)
+	for _, container := range []string{listPropType, mapPropType} {
+		if strings.HasPrefix(s, container+"<") && strings.HasSuffix(s, ">") {
+			return container, TfRsxPropType(s[len(container)+1 : len(s)-1]), true
+		}
+	}
+	return "", "", false
+}
+
+func (t TfRsxPropType) IsValid() bool {
+	if _, elem, ok := t.container(); ok {
+		return elem != Secret && elem.IsValid()
+	}
+	return slices.Contains(TfRsxPropTypes, t)
+}
+
+// CheckValue checks that s is a valid value of the property type, as it's passed to the template: scalars in their
+// canonical text form, Bytes base64 encoded, Timestamps in RFC 3339 form, Durations as understood by Go's
+// time.ParseDuration (e.g. "1h30m"), and Lists, Maps and Json as JSON.
+func (t TfRsxPropType) CheckValue(s string) error {
+	if _, _, ok := t.container(); ok || t == Json {
+		v, err := decodeJson(s)
+		if err != nil {
+			return fmt.Errorf("%q is not valid JSON", s)
+		}
+		return t.checkJson(v)
+	}
+
+	switch t {
+	case Bool:
+		if s != "true" && s != "false" {
+			return fmt.Errorf("%q is not a Bool; expected true or false", s)
+		}
+	case I32, I64:
+		bitSize := 32
+		if t == I64 {
+			bitSize = 64
+		}
+		if _, err := strconv.ParseInt(s, 10, bitSize); err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf("%s is out of range for %s", s, t)
+			}
+			return fmt.Errorf("%q is not an integer", s)
+		}
+	case F32, F64:
+		bitSize := 32
+		if t == F64 {
+			bitSize = 64
+		}
+		f, err := strconv.ParseFloat(s, bitSize)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf("%s is out of range for %s", s, t)
+			}
+			return fmt.Errorf("%q is not a number", s)
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%s is not a finite number", s)
+		}
+	case Bytes:
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return fmt.Errorf("%q is not base64 encoded", s)
+		}
+	case Timestamp:
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("%q is not an RFC 3339 timestamp", s)
+		}
+	case Duration:
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("%q is not a duration", s)
+		}
+	}
+	return nil
+}
+
+// checkJson checks that v, as decoded from JSON, is a valid value of the property type.
+func (t TfRsxPropType) checkJson(v any) error {
+	if container, elem, ok := t.container(); ok {
+		switch container {
+		case listPropType:
+			items, ok := v.([]any)
+			if !ok {
+				return fmt.Errorf("expected a list, got %s", jsonKind(v))
+			}
+			for i, item := range items {
+				if err := elem.checkJson(item); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+		case mapPropType:
+			entries, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("expected a map, got %s", jsonKind(v))
+			}
+			for _, k := range slices.Sorted(maps.Keys(entries)) {
+				if err := elem.checkJson(entries[k]); err != nil {
+					return fmt.Errorf("[%q]: %w", k, err)
+				}
+			}
+		}
+		return nil
+	}
+
+	switch t {
+	case Json:
+		return nil
+	case Bool:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("expected a bool, got %s", jsonKind(v))
+		}
+		return nil
+	case I32, I64, F32, F64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected a number, got %s", jsonKind(v))
+		}
+		return t.CheckValue(n.String())
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %s", jsonKind(v))
+		}
+		return t.CheckValue(s)
+	}
+}
+
+// EncodeValue encodes a value, as written in the configuration, into the form that's passed to the template. Values
+// are represented as strings, *big.Float numbers, bools, []any lists and map[string]any maps or objects.
+func (t TfRsxPropType) EncodeValue(v any) (string, error) {
+	var s string
+	switch {
+	case t == Json:
+		// Strings are taken to be JSON already, as produced by jsonencode().
+		if str, ok := v.(string); ok {
+			s = str
+			break
+		}
+		b, err := json.Marshal(toJson(v))
+		if err != nil {
+			return "", err
+		}
+		s = string(b)
+	case strings.HasPrefix(string(t), listPropType+"<") || strings.HasPrefix(string(t), mapPropType+"<"):
+		jsonVal, err := t.toTypedJson(v)
+		if err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(jsonVal)
+		if err != nil {
+			return "", err
+		}
+		s = string(b)
+	default:
+		scalar, err := scalarText(v)
+		if err != nil {
+			return "", err
+		}
+		s = scalar
+	}
+
+	if err := t.CheckValue(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// toTypedJson converts v into the JSON value of the property type, e.g. numbers given as strings into JSON numbers.
+func (t TfRsxPropType) toTypedJson(v any) (any, error) {
+	if container, elem, ok := t.container(); ok {
+		switch container {
+		case listPropType:
+			items, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("expected a list, got %s", goKind(v))
+			}
+			result := make([]any, len(items))
+			for i, item := range items {
+				jsonItem, err := elem.toTypedJson(item)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+				result[i] = jsonItem
+			}
+			return result, nil
+		default:
+			entries, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected a map, got %s", goKind(v))
+			}
+			result := make(map[string]any)
+			for _, k := range slices.Sorted(maps.Keys(entries)) {
+				jsonEntry, err := elem.toTypedJson(entries[k])
+				if err != nil {
+					return nil, fmt.Errorf("[%q]: %w", k, err)
+				}
+				result[k] = jsonEntry
+			}
+			return result, nil
+		}
+	}
+
+	switch t {
+	case Json:
+		return toJson(v), nil
+	case Bool, I32, I64, F32, F64:
+		s, err := scalarText(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.CheckValue(s); err != nil {
+			return nil, err
+		}
+		if t == Bool {
+			return s == "true", nil
+		}
+		return json.Number(s), nil
+	default:
+		return scalarText(v)
+	}
+}
+
+// DecodeValue decodes a value passed to the template back into the form it's written in the configuration; the
+// inverse of EncodeValue.
+func (t TfRsxPropType) DecodeValue(s string) (any, error) {
+	if err := t.CheckValue(s); err != nil {
+		return nil, err
+	}
+
+	if _, _, ok := t.container(); ok || t == Json {
+		v, err := decodeJson(s)
+		if err != nil {
+			return nil, err
+		}
+		return fromJson(v), nil
+	}
+
+	switch t {
+	case Bool:
+		return s == "true", nil
+	case I32, I64, F32, F64:
+		f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+		return f, err
+	default:
+		return s, nil
+	}
+}
+
+// scalarText renders a string, number or bool as text, the way terraform converts them to strings.
+func scalarText(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case *big.Float:
+		return numberText(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, number or bool, got %s", goKind(v))
+	}
+}
+
+func numberText(f *big.Float) string {
+	if f.IsInt() {
+		return f.Text('f', 0)
+	}
+	return f.Text('g', -1)
+}
+
+// toJson converts a configuration value into a value that encoding/json marshals the same way jsonencode() would.
+func toJson(v any) any {
+	switch v := v.(type) {
+	case *big.Float:
+		return json.Number(numberText(v))
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = toJson(item)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any)
+		for k, entry := range v {
+			result[k] = toJson(entry)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// fromJson converts a value decoded from JSON into a configuration value; the inverse of toJson.
+func fromJson(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = fromJson(item)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any)
+		for k, entry := range v {
+			result[k] = fromJson(entry)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func decodeJson(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a bool"
+	case json.Number:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+func goKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a bool"
+	case *big.Float:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// closestPropType suggests the TfRsxPropType that the given misspelt type most likely meant, if any is close enough.
+func closestPropType(s string) (TfRsxPropType, bool) {
+	if container, elem, ok := TfRsxPropType(s).container(); ok {
+		if elem.IsValid() {
+			return "", false
+		}
+		closestElem, ok := closestPropType(string(elem))
+		if !ok || closestElem == Secret {
+			return "", false
+		}
+		return TfRsxPropType(container + "<" + string(closestElem) + ">"), true
+	}
+
+	var closest TfRsxPropType
+	closestDist := -1
+	for _, propType := range TfRsxPropTypes {
+		dist := editDistance(strings.ToLower(s), strings.ToLower(string(propType)))
+		if closestDist < 0 || dist < closestDist {
+			closest, closestDist = propType, dist
+		}
+	}
+	// Allow about one typo per two characters, so that e.g. "Sting" suggests "Str" but "Number" suggests nothing.
+	if closestDist > len(s)/2+1 {
+		return "", false
+	}
+	return closest, true
+}
+
+// editDistance computes the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func propTypesMarkdown() string {
+	var names []string
+	for _, propType := range TfRsxPropTypes {
+		names = append(names, "`"+string(propType)+"`")
+	}
+	names = append(names, "`List<T>`", "`Map<T>`")
+	return strings.Join(names, ", ")
+}
+
+// propTypesValidator validates that a property's type is a TfRsxPropType.
+type propTypesValidator struct{}
+
+var _ validator.String = propTypesValidator{}
+
+func (v propTypesValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v propTypesValidator) MarkdownDescription(_ context.Context) string {
+	return "must be one of " + propTypesMarkdown()
+}
+
+func (v propTypesValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	propType := TfRsxPropType(req.ConfigValue.ValueString())
+	if propType.IsValid() {
+		return
+	}
+
+	detail := fmt.Sprintf("%q is not a property type; expected one of %s.", propType, propTypesMarkdown())
+	if closest, ok := closestPropType(string(propType)); ok {
+		detail = fmt.Sprintf("%q is not a property type; did you mean %q? Expected one of %s.", propType, closest, propTypesMarkdown())
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Invalid Property Type", detail)
+}
+
+// declaredVarsValidator validates that every key of the vars attribute is declared in the schema attribute alongside
+// it.
+type declaredVarsValidator struct{}
+
+var _ validator.Dynamic = declaredVarsValidator{}
+
+func (v declaredVarsValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v declaredVarsValidator) MarkdownDescription(_ context.Context) string {
+	return "keys must be declared in `schema`"
+}
+
+func (v declaredVarsValidator) ValidateDynamic(ctx context.Context, req validator.DynamicRequest, resp *validator.DynamicResponse) {
+	vars, known, err := varsElements(req.ConfigValue)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Variables", err.Error())
+		return
+	}
+	if !known {
+		return
+	}
+
+	var schema types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if resp.Diagnostics.HasError() || schema.IsNull() || schema.IsUnknown() {
+		return
+	}
+
+	declared := schema.Elements()
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		if _, ok := declared[k]; !ok {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(k),
+				"Undeclared Variable",
+				fmt.Sprintf("Variable %q is not declared in schema; declare its property type in schema to pass it to the template.", k),
+			)
+		}
+	}
+}
+
+// varValuesValidator validates that every var's value parses as the property type that the schema declares for it.
+type varValuesValidator struct{}
+
+var _ resource.ConfigValidator = varValuesValidator{}
+
+func (v varValuesValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v varValuesValidator) MarkdownDescription(_ context.Context) string {
+	return "`vars` values must be valid values of the property types declared in `schema`"
+}
+
+func (v varValuesValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var vars types.Dynamic
+	var schema types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("vars"), &vars)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if resp.Diagnostics.HasError() || schema.IsNull() || schema.IsUnknown() {
+		return
+	}
+	elems, known, err := varsElements(vars)
+	if err != nil || !known {
+		// Reported by declaredVarsValidator, or can't be checked yet.
+		return
+	}
+
+	props := schematize(schema)
+	for _, k := range slices.Sorted(maps.Keys(elems)) {
+		goVal, known := attrToGo(elems[k])
+		if !known || goVal == nil {
+			continue
+		}
+		prop, ok := props[k]
+		if !ok || !prop.Type.IsValid() || prop.checkDecl() != nil {
+			// Undeclared vars and invalid properties are reported elsewhere, and unknown types can't be checked yet.
+			continue
+		}
+
+		encoded, err := prop.Type.EncodeValue(goVal)
+		if err == nil {
+			err = prop.Check(encoded)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("vars").AtMapKey(k),
+				"Invalid Variable Value",
+				fmt.Sprintf("Variable %q is declared as %s in schema, but %s.", k, prop.Type, err),
+			)
+		}
+	}
+}
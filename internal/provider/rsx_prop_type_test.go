@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestClosestPropType(t *testing.T) {
+	cases := map[string]TfRsxPropType{
+		"Sting":   Str,
+		"String":  Str,
+		"str":     Str,
+		"Boolean": Bool,
+		"i23":     I32,
+		"F6":      F64,
+		"Secrt":   Secret,
+		"Number":  "",
+	}
+	for s, expected := range cases {
+		actual, ok := closestPropType(s)
+		if ok != (expected != "") || actual != expected {
+			t.Errorf("expected %q to suggest %q, got %q", s, expected, actual)
+		}
+	}
+}
+
+func TestPropTypesValidator(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]string{
+		"Str":    "",
+		"Sting":  `did you mean "Str"?`,
+		"Number": "expected one of",
+	}
+	for propType, expected := range cases {
+		typePath := path.Root("schema").AtMapKey("a").AtName("type")
+		resp := &validator.StringResponse{}
+		propTypesValidator{}.ValidateString(ctx, validator.StringRequest{Path: typePath, ConfigValue: types.StringValue(propType)}, resp)
+
+		if expected == "" {
+			if resp.Diagnostics.HasError() {
+				t.Errorf("expected %q to be valid, got %v", propType, resp.Diagnostics)
+			}
+			continue
+		}
+		if resp.Diagnostics.ErrorsCount() != 1 {
+			t.Fatalf("expected 1 error for %q, got %v", propType, resp.Diagnostics)
+		}
+		d := resp.Diagnostics.Errors()[0]
+		if !d.(diag.DiagnosticWithPath).Path().Equal(typePath) {
+			t.Errorf("expected error at %s, got %s", typePath, d.(diag.DiagnosticWithPath).Path())
+		}
+		if !strings.Contains(d.Detail(), expected) {
+			t.Errorf("expected %q in %s", expected, d.Detail())
+		}
+		if propType == "Number" && strings.Contains(d.Detail(), "did you mean") {
+			t.Errorf("expected no suggestion, got %s", d.Detail())
+		}
+	}
+}
+
+func TestDeclaredVarsValidator(t *testing.T) {
+	ctx := context.Background()
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+		"vars":   testStringMap(map[string]string{"a": "x", "b": "y"}),
+		"schema": testSchema(map[string]TfRsxPropType{"a": Str}),
+	})
+	vars := types.DynamicValue(types.ObjectValueMust(
+		map[string]attr.Type{"a": types.StringType, "b": types.NumberType},
+		map[string]attr.Value{"a": types.StringValue("x"), "b": types.NumberValue(big.NewFloat(1))},
+	))
+
+	resp := &validator.DynamicResponse{}
+	declaredVarsValidator{}.ValidateDynamic(ctx, validator.DynamicRequest{Path: path.Root("vars"), ConfigValue: vars, Config: config}, resp)
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected 1 error, got %v", resp.Diagnostics)
+	}
+	withPath := resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath)
+	if !withPath.Path().Equal(path.Root("vars").AtMapKey("b")) {
+		t.Errorf("expected error at vars[\"b\"], got %s", withPath.Path())
+	}
+}
+
+func TestCheckValue(t *testing.T) {
+	cases := []struct {
+		propType TfRsxPropType
+		value    string
+		valid    bool
+	}{
+		{Bool, "true", true},
+		{Bool, "false", true},
+		{Bool, "yes", false},
+		{Bool, "1", false},
+		{I32, "-2147483648", true},
+		{I32, "2147483648", false},
+		{I32, "abc", false},
+		{I32, "1.5", false},
+		{I64, "2147483648", true},
+		{I64, "9223372036854775808", false},
+		{F32, "1.5e38", true},
+		{F32, "3.5e38", false},
+		{F64, "3.5e38", true},
+		{F64, "NaN", false},
+		{F64, "Inf", false},
+		{Str, "anything", true},
+		{Secret, "anything", true},
+		{Json, `{"a": [1, null]}`, true},
+		{Json, `{"a": `, false},
+		{Json, `{} {}`, false},
+		{Bytes, "aGVsbG8=", true},
+		{Bytes, "not base64!", false},
+		{Timestamp, "2024-01-02T03:04:05Z", true},
+		{Timestamp, "2024-01-02", false},
+		{Duration, "1h30m", true},
+		{Duration, "90", false},
+		{ListOf(I32), "[1, 2, 3]", true},
+		{ListOf(I32), `[1, "2"]`, false},
+		{ListOf(I32), "[2147483648]", false},
+		{ListOf(I32), `{"a": 1}`, false},
+		{MapOf(Str), `{"a": "x"}`, true},
+		{MapOf(Str), `{"a": 1}`, false},
+		{MapOf(ListOf(Bool)), `{"a": [true, false]}`, true},
+		{ListOf(Timestamp), `["2024-01-02T03:04:05Z", "yesterday"]`, false},
+	}
+	for _, c := range cases {
+		err := c.propType.CheckValue(c.value)
+		if (err == nil) != c.valid {
+			t.Errorf("expected %s %q valid=%t, got %v", c.propType, c.value, c.valid, err)
+		}
+	}
+}
+
+func TestPropTypeIsValid(t *testing.T) {
+	cases := map[TfRsxPropType]bool{
+		"Str":            true,
+		"Timestamp":      true,
+		"List<I32>":      true,
+		"Map<List<Str>>": true,
+		"List<Json>":     true,
+		"List<Secret>":   false,
+		"List<>":         false,
+		"List<Strr>":     false,
+		"List":           false,
+		"Map<Str":        false,
+	}
+	for propType, valid := range cases {
+		if propType.IsValid() != valid {
+			t.Errorf("expected %q valid=%t", propType, valid)
+		}
+	}
+
+	if closest, ok := closestPropType("List<Sting>"); !ok || closest != ListOf(Str) {
+		t.Errorf("expected List<Str>, got %q", closest)
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	cases := []struct {
+		propType TfRsxPropType
+		value    any
+		encoded  string
+	}{
+		{Str, "x", "x"},
+		{Str, big.NewFloat(8080), "8080"},
+		{I32, big.NewFloat(8080), "8080"},
+		{I32, "8080", "8080"},
+		{F64, big.NewFloat(1.5), "1.5"},
+		{Bool, true, "true"},
+		{Bytes, "aGVsbG8=", "aGVsbG8="},
+		{ListOf(I32), []any{big.NewFloat(1), "2"}, "[1,2]"},
+		{MapOf(Bool), map[string]any{"b": false, "a": "true"}, `{"a":true,"b":false}`},
+		{Json, map[string]any{"a": []any{big.NewFloat(1.5), nil}}, `{"a":[1.5,null]}`},
+		{Json, `{"a":1}`, `{"a":1}`},
+	}
+	for _, c := range cases {
+		encoded, err := c.propType.EncodeValue(c.value)
+		if err != nil {
+			t.Errorf("unexpected error encoding %s %v: %s", c.propType, c.value, err)
+			continue
+		}
+		if encoded != c.encoded {
+			t.Errorf("expected %s %v to encode as %s, got %s", c.propType, c.value, c.encoded, encoded)
+		}
+
+		decoded, err := c.propType.DecodeValue(encoded)
+		if err != nil {
+			t.Errorf("unexpected error decoding %s %s: %s", c.propType, encoded, err)
+			continue
+		}
+		reencoded, err := c.propType.EncodeValue(decoded)
+		if err != nil || reencoded != encoded {
+			t.Errorf("expected %s %s to round trip, got %s, %v", c.propType, encoded, reencoded, err)
+		}
+	}
+
+	invalid := []struct {
+		propType TfRsxPropType
+		value    any
+	}{
+		{I32, "abc"},
+		{I32, big.NewFloat(1.5)},
+		{ListOf(Str), "[]"},
+		{ListOf(I32), []any{"x"}},
+		{MapOf(Str), []any{"x"}},
+		{Timestamp, big.NewFloat(1)},
+		{Str, []any{"x"}},
+	}
+	for _, c := range invalid {
+		if encoded, err := c.propType.EncodeValue(c.value); err == nil {
+			t.Errorf("expected %s %v to be invalid, got %s", c.propType, c.value, encoded)
+		}
+	}
+}
+
+func TestVarValuesValidator(t *testing.T) {
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+		"vars":   testStringMap(map[string]string{"size": "abc", "count": "3", "name": "x"}),
+		"schema": testSchema(map[string]TfRsxPropType{"size": I32, "count": I32, "name": Str}),
+	})
+
+	resp := &resource.ValidateConfigResponse{}
+	varValuesValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected 1 error, got %v", resp.Diagnostics)
+	}
+	withPath := resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath)
+	if !withPath.Path().Equal(path.Root("vars").AtMapKey("size")) {
+		t.Errorf("expected error at vars[\"size\"], got %s", withPath.Path())
+	}
+}
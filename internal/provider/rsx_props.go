@@ -0,0 +1,511 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TfRsxProp describes a property of a twin rsx: the type of the var passed to its template (or of the output it
+// publishes), and how the var is to be checked and handled.
+type TfRsxProp struct {
+	Type        TfRsxPropType        `json:"type"`
+	Required    bool                 `json:"required,omitempty"`
+	Default     *string              `json:"default,omitempty"`
+	Sensitive   bool                 `json:"sensitive,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Validation  *TfRsxPropValidation `json:"validation,omitempty"`
+	Extract     *string              `json:"extract,omitempty"`
+}
+
+// TfRsxPropValidation constrains the values of a property beyond its type. Regex applies to Str and Secret values;
+// Min and Max bound the value of numeric properties, and the length of Str, Secret, Bytes, List and Map properties.
+type TfRsxPropValidation struct {
+	Regex *string  `json:"regex,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// TfRsxProps maps the name of each property of a twin rsx to its description.
+type TfRsxProps map[string]TfRsxProp
+
+// IsSecret reports whether the property's values must be kept out of plans, state and logs.
+func (p TfRsxProp) IsSecret() bool {
+	return p.Type == Secret || p.Sensitive
+}
+
+// Types returns the type of each property, as the reactor's schema map expects them.
+func (p TfRsxProps) Types() map[string]TfRsxPropType {
+	result := make(map[string]TfRsxPropType)
+	for k, prop := range p {
+		result[k] = prop.Type
+	}
+	return result
+}
+
+// TypeOf returns the type of the named property, treating undeclared properties as Str.
+func (p TfRsxProps) TypeOf(k string) TfRsxPropType {
+	if prop, ok := p[k]; ok {
+		return prop.Type
+	}
+	return Str
+}
+
+// IsSecret reports whether the named property is declared Secret or sensitive.
+func (p TfRsxProps) IsSecret(k string) bool {
+	return p[k].IsSecret()
+}
+
+// deployed returns the properties without their descriptions and validations, which document and check the vars
+// without changing what the reactor deploys.
+func (p TfRsxProps) deployed() TfRsxProps {
+	result := make(TfRsxProps)
+	for k, prop := range p {
+		prop.Description = ""
+		prop.Validation = nil
+		result[k] = prop
+	}
+	return result
+}
+
+// propsOfTypes describes properties for which only their types are known, e.g. as reported by reactors that predate
+// property metadata. Properties whose type is unchanged keep their prior description.
+func propsOfTypes(types map[string]TfRsxPropType, prior TfRsxProps) TfRsxProps {
+	result := make(TfRsxProps)
+	for k, propType := range types {
+		if priorProp, ok := prior[k]; ok && priorProp.Type == propType {
+			result[k] = priorProp
+		} else {
+			result[k] = TfRsxProp{Type: propType}
+		}
+	}
+	return result
+}
+
+// Check checks that s, the encoded value of a var, is a valid value of the property. Errors don't quote the value, so
+// that they're safe to report for secrets.
+func (p TfRsxProp) Check(s string) error {
+	if err := p.Type.CheckValue(s); err != nil {
+		if p.IsSecret() {
+			return fmt.Errorf("the value is not a valid %s", p.Type)
+		}
+		return err
+	}
+	if p.Validation == nil {
+		return nil
+	}
+
+	if p.Validation.Regex != nil {
+		re, err := regexp.Compile(*p.Validation.Regex)
+		if err != nil {
+			return fmt.Errorf("validation regex is invalid: %w", err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("the value doesn't match %s", *p.Validation.Regex)
+		}
+	}
+
+	if p.Validation.Min != nil || p.Validation.Max != nil {
+		measure, what, err := p.measure(s)
+		if err != nil {
+			return err
+		}
+		if p.Validation.Min != nil && measure < *p.Validation.Min {
+			return fmt.Errorf("the %s is less than the minimum of %s", what, strconv.FormatFloat(*p.Validation.Min, 'g', -1, 64))
+		}
+		if p.Validation.Max != nil && measure > *p.Validation.Max {
+			return fmt.Errorf("the %s is greater than the maximum of %s", what, strconv.FormatFloat(*p.Validation.Max, 'g', -1, 64))
+		}
+	}
+	return nil
+}
+
+// measure returns what a property's Min and Max bound: the value itself for numbers, the length otherwise.
+func (p TfRsxProp) measure(s string) (float64, string, error) {
+	if container, _, ok := p.Type.container(); ok {
+		v, err := decodeJson(s)
+		if err != nil {
+			return 0, "", err
+		}
+		if container == listPropType {
+			return float64(len(v.([]any))), "length", nil
+		}
+		return float64(len(v.(map[string]any))), "length", nil
+	}
+
+	switch p.Type {
+	case I32, I64, F32, F64:
+		f, err := strconv.ParseFloat(s, 64)
+		return f, "value", err
+	case Str, Secret:
+		return float64(utf8.RuneCountInString(s)), "length", nil
+	case Bytes:
+		b, err := base64.StdEncoding.DecodeString(s)
+		return float64(len(b)), "length", err
+	default:
+		return 0, "", fmt.Errorf("min and max don't apply to %s properties", p.Type)
+	}
+}
+
+// checkDecl checks that the property's declaration is consistent: that its validation applies to its type, and that
+// its default is a valid value of it.
+func (p TfRsxProp) checkDecl() error {
+	if !p.Type.IsValid() {
+		// Reported by propTypesValidator.
+		return nil
+	}
+
+	if v := p.Validation; v != nil {
+		if v.Regex != nil {
+			if p.Type != Str && p.Type != Secret {
+				return fmt.Errorf("validation regex only applies to Str and Secret properties, not %s", p.Type)
+			}
+			if _, err := regexp.Compile(*v.Regex); err != nil {
+				return fmt.Errorf("validation regex is invalid: %w", err)
+			}
+		}
+		if v.Min != nil || v.Max != nil {
+			if _, _, err := p.measure(zeroValue(p.Type)); err != nil {
+				return err
+			}
+		}
+		if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+			return errors.New("validation min is greater than max")
+		}
+	}
+
+	if p.Extract != nil {
+		if _, err := parseOutputExtraction(*p.Extract); err != nil {
+			return fmt.Errorf("extract is invalid: %w", err)
+		}
+	}
+
+	if p.Default != nil {
+		if p.Type == Secret {
+			return errors.New("Secret properties can't have a default")
+		}
+		if p.Required {
+			return errors.New("required properties can't have a default")
+		}
+		if err := p.Check(*p.Default); err != nil {
+			return fmt.Errorf("default is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// zeroValue returns a valid encoded value of the property type.
+func zeroValue(t TfRsxPropType) string {
+	if container, _, ok := t.container(); ok {
+		if container == listPropType {
+			return "[]"
+		}
+		return "{}"
+	}
+	switch t {
+	case Bool:
+		return "false"
+	case I32, I64, F32, F64:
+		return "0"
+	case Json:
+		return "null"
+	case Timestamp:
+		return time.Time{}.Format(time.RFC3339)
+	case Duration:
+		return "0s"
+	default:
+		return ""
+	}
+}
+
+// The attributes of each property in the schema attribute.
+var rsxPropValidationAttrTypes = map[string]attr.Type{
+	"regex": types.StringType,
+	"min":   types.Float64Type,
+	"max":   types.Float64Type,
+}
+
+var rsxPropAttrTypes = map[string]attr.Type{
+	"type":        types.StringType,
+	"required":    types.BoolType,
+	"default":     types.StringType,
+	"sensitive":   types.BoolType,
+	"description": types.StringType,
+	"validation":  types.ObjectType{AttrTypes: rsxPropValidationAttrTypes},
+	"extract":     types.StringType,
+}
+
+func rsxPropAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"type": schema.StringAttribute{
+			Required:            true,
+			MarkdownDescription: "The type of the property, one of " + propTypesMarkdown(),
+			Validators: []validator.String{
+				propTypesValidator{},
+			},
+		},
+		"required": schema.BoolAttribute{
+			Optional:            true,
+			MarkdownDescription: "Whether a value must be passed for the property in `vars` or `secret_vars`",
+		},
+		"default": schema.StringAttribute{
+			Optional:            true,
+			MarkdownDescription: "The value the template uses when none is passed for the property, encoded as it's passed to the template",
+		},
+		"sensitive": schema.BoolAttribute{
+			Optional: true,
+			MarkdownDescription: "Whether the property's values are sensitive. Sensitive vars are passed in `secret_vars` and sensitive " +
+				"outputs are published in `secret_outputs`, like `Secret` ones. Implied by the `Secret` type",
+		},
+		"description": schema.StringAttribute{
+			Optional:            true,
+			MarkdownDescription: "A description of the property",
+		},
+		"validation": schema.SingleNestedAttribute{
+			Optional:            true,
+			MarkdownDescription: "Constraints on the property's values beyond its type",
+			Attributes: map[string]schema.Attribute{
+				"regex": schema.StringAttribute{
+					Optional:            true,
+					MarkdownDescription: "A regular expression that `Str` and `Secret` values must match",
+				},
+				"min": schema.Float64Attribute{
+					Optional:            true,
+					MarkdownDescription: "The minimum value of numeric properties, or the minimum length of `Str`, `Secret`, `Bytes`, `List<T>` and `Map<T>` properties",
+				},
+				"max": schema.Float64Attribute{
+					Optional:            true,
+					MarkdownDescription: "The maximum value of numeric properties, or the maximum length of `Str`, `Secret`, `Bytes`, `List<T>` and `Map<T>` properties",
+				},
+			},
+		},
+		"extract": schema.StringAttribute{
+			Optional: true,
+			MarkdownDescription: "Where the output of the property is extracted from, for templates that don't declare outputs such as " +
+				"`KubernetesYaml` ones: an object of the template and a path into it, e.g. `Service/default/web:.status.loadBalancer.ingress[0].ip`",
+		},
+	}
+}
+
+// schematize reads the properties declared in the schema attribute, skipping any that aren't known yet.
+func schematize(attrMap types.Map) TfRsxProps {
+	result := make(TfRsxProps)
+	for k, v := range attrMap.Elements() {
+		obj, ok := v.(types.Object)
+		if !ok || obj.IsNull() || obj.IsUnknown() {
+			continue
+		}
+		attrs := obj.Attributes()
+		propType, ok := knownString(attrs["type"])
+		if !ok {
+			continue
+		}
+
+		prop := TfRsxProp{Type: TfRsxPropType(propType)}
+		prop.Required, _ = knownBool(attrs["required"])
+		prop.Sensitive, _ = knownBool(attrs["sensitive"])
+		prop.Description, _ = knownString(attrs["description"])
+		if def, ok := knownString(attrs["default"]); ok {
+			prop.Default = &def
+		}
+		if extract, ok := knownString(attrs["extract"]); ok {
+			prop.Extract = &extract
+		}
+		if validation, ok := attrs["validation"].(types.Object); ok && !validation.IsNull() && !validation.IsUnknown() {
+			prop.Validation = &TfRsxPropValidation{}
+			validationAttrs := validation.Attributes()
+			if regex, ok := knownString(validationAttrs["regex"]); ok {
+				prop.Validation.Regex = &regex
+			}
+			if min, ok := knownFloat(validationAttrs["min"]); ok {
+				prop.Validation.Min = &min
+			}
+			if max, ok := knownFloat(validationAttrs["max"]); ok {
+				prop.Validation.Max = &max
+			}
+		}
+		result[k] = prop
+	}
+	return result
+}
+
+// propsValue builds the schema attribute from the properties of a twin rsx, leaving unset metadata null as it is when
+// omitted from the config.
+func propsValue(props TfRsxProps) (types.Map, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	nonZeroBool := func(b bool) types.Bool {
+		if !b {
+			return types.BoolNull()
+		}
+		return types.BoolValue(true)
+	}
+	nonZeroString := func(s string) types.String {
+		if s == "" {
+			return types.StringNull()
+		}
+		return types.StringValue(s)
+	}
+
+	elems := make(map[string]attr.Value)
+	for k, prop := range props {
+		validation := types.ObjectNull(rsxPropValidationAttrTypes)
+		if prop.Validation != nil {
+			obj, d := types.ObjectValue(rsxPropValidationAttrTypes, map[string]attr.Value{
+				"regex": types.StringPointerValue(prop.Validation.Regex),
+				"min":   types.Float64PointerValue(prop.Validation.Min),
+				"max":   types.Float64PointerValue(prop.Validation.Max),
+			})
+			diags.Append(d...)
+			validation = obj
+		}
+
+		obj, d := types.ObjectValue(rsxPropAttrTypes, map[string]attr.Value{
+			"type":        types.StringValue(string(prop.Type)),
+			"required":    nonZeroBool(prop.Required),
+			"default":     types.StringPointerValue(prop.Default),
+			"sensitive":   nonZeroBool(prop.Sensitive),
+			"description": nonZeroString(prop.Description),
+			"validation":  validation,
+			"extract":     types.StringPointerValue(prop.Extract),
+		})
+		diags.Append(d...)
+		elems[k] = obj
+	}
+
+	result, d := types.MapValue(types.ObjectType{AttrTypes: rsxPropAttrTypes}, elems)
+	diags.Append(d...)
+	return result, diags
+}
+
+// refreshProps rebuilds the schema attribute from the properties the reactor reports, keeping the prior value when
+// it describes the same properties.
+func refreshProps(prior types.Map, props TfRsxProps) (types.Map, diag.Diagnostics) {
+	if !prior.IsNull() && !prior.IsUnknown() && reflect.DeepEqual(schematize(prior), props) {
+		return prior, nil
+	}
+	return propsValue(props)
+}
+
+func knownString(v attr.Value) (string, bool) {
+	s, ok := v.(types.String)
+	if !ok || s.IsNull() || s.IsUnknown() {
+		return "", false
+	}
+	return s.ValueString(), true
+}
+
+func knownBool(v attr.Value) (bool, bool) {
+	b, ok := v.(types.Bool)
+	if !ok || b.IsNull() || b.IsUnknown() {
+		return false, false
+	}
+	return b.ValueBool(), true
+}
+
+func knownFloat(v attr.Value) (float64, bool) {
+	f, ok := v.(types.Float64)
+	if !ok || f.IsNull() || f.IsUnknown() {
+		return 0, false
+	}
+	return f.ValueFloat64(), true
+}
+
+// propDeclsValidator validates that the declaration of each property in a schema map is consistent.
+type propDeclsValidator struct{}
+
+var _ validator.Map = propDeclsValidator{}
+
+func (v propDeclsValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v propDeclsValidator) MarkdownDescription(_ context.Context) string {
+	return "each property's `validation` must apply to its type, and its `default` must be a valid value of it"
+}
+
+func (v propDeclsValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	props := schematize(req.ConfigValue)
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		if err := props[k].checkDecl(); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(k),
+				"Invalid Property",
+				fmt.Sprintf("Property %q is invalid: %s.", k, err),
+			)
+		}
+	}
+}
+
+// requiredVarsValidator validates that a var is passed for every required property.
+type requiredVarsValidator struct{}
+
+var _ resource.ConfigValidator = requiredVarsValidator{}
+
+func (v requiredVarsValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v requiredVarsValidator) MarkdownDescription(_ context.Context) string {
+	return "vars must be passed for every property declared `required` in `schema`"
+}
+
+func (v requiredVarsValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var vars types.Dynamic
+	var secretVars, schema types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("vars"), &vars)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secret_vars"), &secretVars)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if resp.Diagnostics.HasError() || schema.IsUnknown() || secretVars.IsUnknown() {
+		return
+	}
+	varElems, known, err := varsElements(vars)
+	if err != nil || !known {
+		return
+	}
+
+	props := schematize(schema)
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		if !props[k].Required {
+			continue
+		}
+		passed := varElems[k]
+		if props[k].IsSecret() {
+			passed = secretVars.Elements()[k]
+		}
+		if passed == nil || passed.IsNull() {
+			attribute := "vars"
+			if props[k].IsSecret() {
+				attribute = "secret_vars"
+			}
+			resp.Diagnostics.AddAttributeError(
+				path.Root("schema").AtMapKey(k),
+				"Missing Required Variable",
+				fmt.Sprintf("Variable %q is declared required in schema, but isn't passed in %s.", k, attribute),
+			)
+		}
+	}
+}
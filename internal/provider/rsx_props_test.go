@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRsxPropCheck(t *testing.T) {
+	regex := "^[a-z]+$"
+	one, three := 1.0, 3.0
+	cases := []struct {
+		prop  TfRsxProp
+		value string
+		valid bool
+	}{
+		{TfRsxProp{Type: Str, Validation: &TfRsxPropValidation{Regex: &regex}}, "abc", true},
+		{TfRsxProp{Type: Str, Validation: &TfRsxPropValidation{Regex: &regex}}, "ABC", false},
+		{TfRsxProp{Type: Str, Validation: &TfRsxPropValidation{Min: &one, Max: &three}}, "abc", true},
+		{TfRsxProp{Type: Str, Validation: &TfRsxPropValidation{Min: &one, Max: &three}}, "abcd", false},
+		{TfRsxProp{Type: Str, Validation: &TfRsxPropValidation{Min: &one}}, "", false},
+		{TfRsxProp{Type: I32, Validation: &TfRsxPropValidation{Min: &one, Max: &three}}, "3", true},
+		{TfRsxProp{Type: I32, Validation: &TfRsxPropValidation{Min: &one, Max: &three}}, "0", false},
+		{TfRsxProp{Type: ListOf(Str), Validation: &TfRsxPropValidation{Max: &one}}, `["a"]`, true},
+		{TfRsxProp{Type: ListOf(Str), Validation: &TfRsxPropValidation{Max: &one}}, `["a","b"]`, false},
+		{TfRsxProp{Type: Bytes, Validation: &TfRsxPropValidation{Max: &three}}, "aGVsbG8=", false},
+	}
+	for _, c := range cases {
+		if err := c.prop.Check(c.value); (err == nil) != c.valid {
+			t.Errorf("expected %+v %q valid=%t, got %v", c.prop, c.value, c.valid, err)
+		}
+	}
+
+	secret := TfRsxProp{Type: I32, Sensitive: true}
+	if err := secret.Check("hunter2"); err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("expected an error that doesn't quote the secret, got %v", err)
+	}
+}
+
+func TestPropDeclsValidator(t *testing.T) {
+	regex, badRegex := "^[a-z]+$", "(["
+	one, three := 1.0, 3.0
+	def, badDef := "8080", "abc"
+	extract, badExtract := "Service/default/web:.spec.clusterIP", "Service/default/web.spec.clusterIP"
+	props := TfRsxProps{
+		"name":       {Type: Str, Validation: &TfRsxPropValidation{Regex: &regex, Min: &one, Max: &three}},
+		"port":       {Type: I32, Default: &def},
+		"badRegex":   {Type: Str, Validation: &TfRsxPropValidation{Regex: &badRegex}},
+		"intRegex":   {Type: I32, Validation: &TfRsxPropValidation{Regex: &regex}},
+		"boolMin":    {Type: Bool, Validation: &TfRsxPropValidation{Min: &one}},
+		"minMax":     {Type: I32, Validation: &TfRsxPropValidation{Min: &three, Max: &one}},
+		"badDef":     {Type: I32, Default: &badDef},
+		"secretDef":  {Type: Secret, Default: &def},
+		"reqDef":     {Type: I32, Required: true, Default: &def},
+		"ip":         {Type: Str, Extract: &extract},
+		"badExtract": {Type: Str, Extract: &badExtract},
+	}
+	schema, diags := propsValue(props)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	resp := &validator.MapResponse{}
+	propDeclsValidator{}.ValidateMap(context.Background(), validator.MapRequest{Path: path.Root("schema"), ConfigValue: schema}, resp)
+
+	var invalid []string
+	for _, d := range resp.Diagnostics.Errors() {
+		for k := range props {
+			if d.(diag.DiagnosticWithPath).Path().Equal(path.Root("schema").AtMapKey(k)) {
+				invalid = append(invalid, k)
+			}
+		}
+	}
+	expected := []string{"badDef", "badExtract", "badRegex", "boolMin", "intRegex", "minMax", "reqDef", "secretDef"}
+	if !reflect.DeepEqual(invalid, expected) {
+		t.Errorf("expected %v to be invalid, got %v", expected, invalid)
+	}
+}
+
+func TestRequiredVarsValidator(t *testing.T) {
+	schema, diags := propsValue(TfRsxProps{
+		"name":     {Type: Str, Required: true},
+		"port":     {Type: I32, Required: true},
+		"size":     {Type: I32},
+		"password": {Type: Secret, Required: true},
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	schemaVal, err := schema.ToTerraformValue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+		"vars":   testStringMap(map[string]string{"name": "x"}),
+		"schema": schemaVal,
+	})
+
+	resp := &resource.ValidateConfigResponse{}
+	requiredVarsValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+	expected := map[string]bool{
+		path.Root("schema").AtMapKey("port").String():     true,
+		path.Root("schema").AtMapKey("password").String(): true,
+	}
+	if resp.Diagnostics.ErrorsCount() != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), resp.Diagnostics)
+	}
+	for _, d := range resp.Diagnostics.Errors() {
+		if p := d.(diag.DiagnosticWithPath).Path().String(); !expected[p] {
+			t.Errorf("unexpected error at %s", p)
+		}
+	}
+}
+
+func TestRefreshProps(t *testing.T) {
+	def := "8080"
+	props := TfRsxProps{"port": {Type: I32, Default: &def, Description: "The port"}}
+	prior, diags := propsValue(props)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	// Reactors that only report types keep the metadata in state.
+	refreshed, _ := refreshProps(prior, (&TfLoFiTwinRsx{Schema: &map[string]TfRsxPropType{"port": I32}}).props(schematize(prior)))
+	if !refreshed.Equal(prior) {
+		t.Errorf("expected %s to be kept, got %s", prior, refreshed)
+	}
+
+	refreshed, _ = refreshProps(prior, (&TfLoFiTwinRsx{Schema: &map[string]TfRsxPropType{"port": I64}}).props(schematize(prior)))
+	if !reflect.DeepEqual(schematize(refreshed), TfRsxProps{"port": {Type: I64}}) {
+		t.Errorf("expected the changed type to be picked up, got %s", refreshed)
+	}
+}
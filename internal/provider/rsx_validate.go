@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// TfRsxFinding is a problem that the reactor found with a twin rsx, e.g. when validating it against the resources that
+// templates may use, the quotas of its projection, or customer policy.
+type TfRsxFinding struct {
+	// Severity is Error or Warning; findings of other severities are reported as warnings.
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	// Path is the attribute that the finding is about, e.g. template or vars["size"]. Findings about the twin rsx as a
+	// whole have no path.
+	Path *string `json:"path,omitempty"`
+}
+
+var findingPathRoot = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+var findingPathStep = regexp.MustCompile(`^(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[([0-9]+)\]|\["((?:[^"\\]|\\.)*)"\])`)
+
+// parseFindingPath parses the path of a finding: an attribute followed by .name steps into objects, ["key"] steps into
+// maps and [index] steps into lists, e.g. schema["size"].validation.
+func parseFindingPath(s string) (path.Path, error) {
+	root := findingPathRoot.FindString(s)
+	if root == "" {
+		return path.Empty(), fmt.Errorf("%q doesn't start with an attribute name", s)
+	}
+
+	p := path.Root(root)
+	for rest := s[len(root):]; rest != ""; {
+		match := findingPathStep.FindStringSubmatch(rest)
+		if match == nil {
+			return path.Empty(), fmt.Errorf("%q isn't a path of .name, [\"key\"] and [index] steps", s)
+		}
+		switch {
+		case match[1] != "":
+			p = p.AtName(match[1])
+		case match[2] != "":
+			index, err := strconv.ParseInt(match[2], 10, 64)
+			if err != nil {
+				return path.Empty(), err
+			}
+			p = p.AtListIndex(int(index))
+		default:
+			key, err := strconv.Unquote(`"` + match[3] + `"`)
+			if err != nil {
+				return path.Empty(), fmt.Errorf("%q has an invalid key: %w", s, err)
+			}
+			p = p.AtMapKey(key)
+		}
+		rest = rest[len(match[0]):]
+	}
+	return p, nil
+}
+
+// findingDiagnostics turns the findings of the reactor into diagnostics, at the attributes they're about. Findings whose
+// path doesn't parse are reported for the twin rsx as a whole, naming their path.
+func findingDiagnostics(findings []TfRsxFinding) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, finding := range findings {
+		detail := finding.Detail
+		var findingPath *path.Path
+		if finding.Path != nil {
+			if p, err := parseFindingPath(*finding.Path); err == nil {
+				findingPath = &p
+			} else {
+				detail = fmt.Sprintf("%s\n\nAt %s.", detail, *finding.Path)
+			}
+		}
+
+		switch {
+		case finding.Severity == "Error" && findingPath != nil:
+			diags.AddAttributeError(*findingPath, finding.Summary, detail)
+		case finding.Severity == "Error":
+			diags.AddError(finding.Summary, detail)
+		case findingPath != nil:
+			diags.AddAttributeWarning(*findingPath, finding.Summary, detail)
+		default:
+			diags.AddWarning(finding.Summary, detail)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestParseFindingPath(t *testing.T) {
+	cases := map[string]path.Path{
+		`template`:                      path.Root("template"),
+		`vars["size"]`:                  path.Root("vars").AtMapKey("size"),
+		`vars["a \"quoted\" key"]`:      path.Root("vars").AtMapKey(`a "quoted" key`),
+		`schema["size"].validation.min`: path.Root("schema").AtMapKey("size").AtName("validation").AtName("min"),
+		`vars["zones"][1]`:              path.Root("vars").AtMapKey("zones").AtListIndex(1),
+	}
+	for s, expected := range cases {
+		p, err := parseFindingPath(s)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", s, err)
+			continue
+		}
+		if !p.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s", s, expected, p)
+		}
+	}
+
+	for _, s := range []string{``, `["size"]`, `vars[size]`, `vars.`, `vars["size"`} {
+		if _, err := parseFindingPath(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestFindingDiagnostics(t *testing.T) {
+	diags := findingDiagnostics([]TfRsxFinding{
+		{Severity: "Error", Summary: "Quota Exceeded", Detail: "Too big.", Path: ptr(`vars["size"]`)},
+		{Severity: "Warning", Summary: "Deprecated Resource", Detail: "Use something else."},
+		{Severity: "Notice", Summary: "Policy Applied", Detail: "Tagged.", Path: ptr(`vars[size]`)},
+		{Severity: "Error", Summary: "Forbidden Resource", Detail: "Not allowed."},
+	})
+
+	if len(diags) != 4 || diags.ErrorsCount() != 2 {
+		t.Fatalf("expected 2 errors and 2 warnings, got %v", diags)
+	}
+	withPath, ok := diags[0].(diag.DiagnosticWithPath)
+	if !ok || diags[0].Severity() != diag.SeverityError || !withPath.Path().Equal(path.Root("vars").AtMapKey("size")) {
+		t.Errorf("expected an error at vars[\"size\"], got %v", diags[0])
+	}
+	if _, ok := diags[1].(diag.DiagnosticWithPath); ok || diags[1].Severity() != diag.SeverityWarning {
+		t.Errorf("expected a warning without a path, got %v", diags[1])
+	}
+	if _, ok := diags[2].(diag.DiagnosticWithPath); ok || diags[2].Severity() != diag.SeverityWarning ||
+		diags[2].Detail() != "Tagged.\n\nAt vars[size]." {
+		t.Errorf("expected a warning naming its unparsable path, got %v", diags[2])
+	}
+	if _, ok := diags[3].(diag.DiagnosticWithPath); ok || diags[3].Severity() != diag.SeverityError {
+		t.Errorf("expected an error without a path, got %v", diags[3])
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// varsElements returns the elements of the dynamic vars attribute, which holds an object when written with HCL's
+// { ... } syntax, or a map when passed a map-typed expression. known is false while the set of vars isn't known yet.
+func varsElements(vars types.Dynamic) (map[string]attr.Value, bool, error) {
+	if vars.IsNull() || vars.IsUnderlyingValueNull() {
+		return map[string]attr.Value{}, true, nil
+	}
+	if vars.IsUnknown() || vars.IsUnderlyingValueUnknown() {
+		return nil, false, nil
+	}
+
+	switch v := vars.UnderlyingValue().(type) {
+	case basetypes.ObjectValue:
+		return v.Attributes(), true, nil
+	case basetypes.MapValue:
+		return v.Elements(), true, nil
+	default:
+		return nil, false, fmt.Errorf("vars must be a map or an object, got %s", v.Type(context.Background()))
+	}
+}
+
+// attrToGo converts a configuration value into the Go value that TfRsxPropType.EncodeValue expects. known is false if
+// the value, or any value nested inside it, isn't known yet.
+func attrToGo(v attr.Value) (any, bool) {
+	if v.IsUnknown() {
+		return nil, false
+	}
+	if v.IsNull() {
+		return nil, true
+	}
+
+	switch v := v.(type) {
+	case basetypes.DynamicValue:
+		if v.IsUnderlyingValueUnknown() {
+			return nil, false
+		}
+		if v.IsUnderlyingValueNull() {
+			return nil, true
+		}
+		return attrToGo(v.UnderlyingValue())
+	case basetypes.StringValue:
+		return v.ValueString(), true
+	case basetypes.NumberValue:
+		return v.ValueBigFloat(), true
+	case basetypes.Int64Value:
+		return new(big.Float).SetInt64(v.ValueInt64()), true
+	case basetypes.Float64Value:
+		return big.NewFloat(v.ValueFloat64()), true
+	case basetypes.BoolValue:
+		return v.ValueBool(), true
+	case basetypes.ListValue:
+		return attrsToGoSlice(v.Elements())
+	case basetypes.SetValue:
+		return attrsToGoSlice(v.Elements())
+	case basetypes.TupleValue:
+		return attrsToGoSlice(v.Elements())
+	case basetypes.MapValue:
+		return attrsToGoMap(v.Elements())
+	case basetypes.ObjectValue:
+		return attrsToGoMap(v.Attributes())
+	default:
+		return v.String(), true
+	}
+}
+
+func attrsToGoSlice(elems []attr.Value) (any, bool) {
+	result := make([]any, len(elems))
+	for i, elem := range elems {
+		goElem, known := attrToGo(elem)
+		if !known {
+			return nil, false
+		}
+		result[i] = goElem
+	}
+	return result, true
+}
+
+func attrsToGoMap(elems map[string]attr.Value) (any, bool) {
+	result := make(map[string]any)
+	for k, elem := range elems {
+		goElem, known := attrToGo(elem)
+		if !known {
+			return nil, false
+		}
+		result[k] = goElem
+	}
+	return result, true
+}
+
+// goToAttr converts a Go value decoded by TfRsxPropType.DecodeValue back into a configuration value. Lists become
+// tuples and maps become objects, the types HCL gives the equivalent [ ... ] and { ... } literals.
+func goToAttr(v any) attr.Value {
+	switch v := v.(type) {
+	case string:
+		return types.StringValue(v)
+	case *big.Float:
+		return types.NumberValue(v)
+	case bool:
+		return types.BoolValue(v)
+	case []any:
+		elemTypes := make([]attr.Type, len(v))
+		elems := make([]attr.Value, len(v))
+		for i, item := range v {
+			elems[i] = goToAttr(item)
+			elemTypes[i] = elems[i].Type(context.Background())
+		}
+		return basetypes.NewTupleValueMust(elemTypes, elems)
+	case map[string]any:
+		attrTypes := make(map[string]attr.Type)
+		attrs := make(map[string]attr.Value)
+		for k, entry := range v {
+			attrs[k] = goToAttr(entry)
+			attrTypes[k] = attrs[k].Type(context.Background())
+		}
+		return types.ObjectValueMust(attrTypes, attrs)
+	default:
+		return types.StringNull()
+	}
+}
+
+// encodeVars encodes each of the vars into the form that's passed to the template, according to the property type
+// the schema declares for it. Undeclared vars are passed as they'd be passed as a Str.
+func encodeVars(vars types.Dynamic, props TfRsxProps) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	elems, known, err := varsElements(vars)
+	if err != nil {
+		diags.AddAttributeError(path.Root("vars"), "Invalid Variables", err.Error())
+		return nil, diags
+	}
+	if !known {
+		diags.AddAttributeError(path.Root("vars"), "Unknown Variables", "vars must be known to pass them to the template.")
+		return nil, diags
+	}
+
+	result := make(map[string]string)
+	for _, k := range slices.Sorted(maps.Keys(elems)) {
+		propType := props.TypeOf(k)
+		goVal, known := attrToGo(elems[k])
+		if !known {
+			diags.AddAttributeError(path.Root("vars").AtMapKey(k), "Unknown Variable", fmt.Sprintf("Variable %q must be known to pass it to the template.", k))
+			continue
+		}
+		if goVal == nil {
+			// Null vars are left for the template to default.
+			continue
+		}
+
+		encoded, err := propType.EncodeValue(goVal)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("vars").AtMapKey(k),
+				"Invalid Variable Value",
+				fmt.Sprintf("Variable %q is declared as %s in schema, but %s.", k, propType, err),
+			)
+			continue
+		}
+		result[k] = encoded
+	}
+	return result, diags
+}
+
+// refreshVars rebuilds the vars attribute from the encoded vars the reactor reports. Vars whose encoding is unchanged
+// keep the value they were written with, so that e.g. a number passed to a Str var doesn't read back as a string.
+func refreshVars(prior types.Dynamic, encoded map[string]string, props TfRsxProps) types.Dynamic {
+	priorElems, known, err := varsElements(prior)
+	if err != nil || !known {
+		priorElems = map[string]attr.Value{}
+	}
+
+	attrTypes := make(map[string]attr.Type)
+	attrs := make(map[string]attr.Value)
+	// Null vars aren't passed to the template, so they're kept as written.
+	for k, priorElem := range priorElems {
+		if _, ok := encoded[k]; !ok && priorElem.IsNull() {
+			attrs[k] = priorElem
+			attrTypes[k] = priorElem.Type(context.Background())
+		}
+	}
+
+	unchanged := len(priorElems) == len(encoded)+len(attrs)
+	for k, s := range encoded {
+		propType := props.TypeOf(k)
+		if priorElem, ok := priorElems[k]; ok {
+			if goVal, known := attrToGo(priorElem); known && goVal != nil {
+				if priorEncoded, err := propType.EncodeValue(goVal); err == nil && priorEncoded == s {
+					attrs[k] = priorElem
+					attrTypes[k] = priorElem.Type(context.Background())
+					continue
+				}
+			}
+		}
+
+		unchanged = false
+		if goVal, err := propType.DecodeValue(s); err == nil {
+			attrs[k] = goToAttr(goVal)
+		} else {
+			attrs[k] = types.StringValue(s)
+		}
+		attrTypes[k] = attrs[k].Type(context.Background())
+	}
+
+	// Keep the prior value as is when nothing changed, so that a map keeps being a map.
+	if unchanged && known && !prior.IsNull() {
+		return prior
+	}
+	return types.DynamicValue(types.ObjectValueMust(attrTypes, attrs))
+}
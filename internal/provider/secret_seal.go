@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Secret vars are sealed with envelope encryption: each value is encrypted with a fresh AES-256-GCM data key, which is
+// in turn wrapped with the projection's RSA public key using RSA-OAEP with SHA-256. Only the appliance holding the
+// projection's private key can open them; the vctrl merely relays the ciphertext.
+const SealedSecretAlg = "RSA-OAEP-256+A256GCM"
+
+// TfSealedSecret is a secret var sealed for the appliance of a projection.
+type TfSealedSecret struct {
+	Alg        string `json:"alg"`
+	KeyId      string `json:"keyId"`
+	WrappedKey []byte `json:"wrappedKey"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// TfProjectionKeyReq asks the reactor for the public key that secrets for a projection are sealed with.
+type TfProjectionKeyReq struct {
+	ApiKey       string `json:"apiKey"`
+	ProjectionId string `json:"projectionId"`
+}
+
+type TfProjectionKey struct {
+	ProjectionId string `json:"projectionId"`
+	KeyId        string `json:"keyId"`
+	Alg          string `json:"alg"`
+	// PublicKey is the PEM encoded RSA public key of the projection's appliance.
+	PublicKey string `json:"publicKey"`
+}
+
+// ProjectionKey is the public key that secrets for a projection are sealed with.
+type ProjectionKey struct {
+	KeyId string
+	Key   *rsa.PublicKey
+}
+
+// ParseProjectionKey parses a PEM encoded RSA public key, in either PKIX or PKCS #1 form. Keys without a key id are
+// identified by the SHA-256 fingerprint of their PKIX encoding.
+func ParseProjectionKey(keyId string, publicKeyPem string) (*ProjectionKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+
+	var key *rsa.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an RSA public key, got %T", pub)
+		}
+		key = rsaPub
+	case "RSA PUBLIC KEY":
+		rsaPub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		key = rsaPub
+	default:
+		return nil, fmt.Errorf("expected a PUBLIC KEY or RSA PUBLIC KEY, got %s", block.Type)
+	}
+
+	if key.Size() < 256 {
+		return nil, fmt.Errorf("RSA public keys must be at least 2048 bits, got %d", key.Size()*8)
+	}
+
+	if keyId == "" {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fingerprint public key: %w", err)
+		}
+		sum := sha256.Sum256(der)
+		keyId = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return &ProjectionKey{KeyId: keyId, Key: key}, nil
+}
+
+// sealedSecretAad binds a sealed secret to the projection and var it was sealed for, so that it can't be replayed as
+// the value of another var.
+func sealedSecretAad(projectionId string, name string) []byte {
+	return []byte(projectionId + "\x00" + name)
+}
+
+// SealSecret encrypts the value of the named secret var for the appliance of a projection.
+func SealSecret(key *ProjectionKey, projectionId string, name string, value string) (*TfSealedSecret, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	gcm, err := newGcm(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key.Key, dataKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return &TfSealedSecret{
+		Alg:        SealedSecretAlg,
+		KeyId:      key.KeyId,
+		WrappedKey: wrappedKey,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(value), sealedSecretAad(projectionId, name)),
+	}, nil
+}
+
+// OpenSecret decrypts the value of the named secret var sealed for the appliance of a projection, given the
+// appliance's private key.
+func OpenSecret(key *rsa.PrivateKey, projectionId string, name string, sealed *TfSealedSecret) (string, error) {
+	if sealed.Alg != SealedSecretAlg {
+		return "", fmt.Errorf("unsupported alg %q", sealed.Alg)
+	}
+
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), nil, key, sealed.WrappedKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	gcm, err := newGcm(dataKey)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed.Nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("invalid nonce size %d", len(sealed.Nonce))
+	}
+
+	value, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, sealedSecretAad(projectionId, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+	return string(value), nil
+}
+
+func newGcm(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init AES-GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// ProjectionKey returns the public key that secrets for a projection are sealed with, preferring keys configured
+// locally over the keys the reactor publishes.
+func (c *ReactorClient) ProjectionKey(ctx context.Context, projectionId string) (*ProjectionKey, error) {
+	if publicKeyPem, ok := c.opts.ProjectionKeys[projectionId]; ok {
+		key, err := ParseProjectionKey("", publicKeyPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key configured for projection %s: %w", projectionId, err)
+		}
+		return key, nil
+	}
+
+	if cached, ok := c.projectionKeys.Load(projectionId); ok {
+		return cached.(*ProjectionKey), nil
+	}
+
+	var projectionKey TfProjectionKey
+	err := c.post(ctx, KeysSvc, &TfProjectionKeyReq{ApiKey: c.apiKey, ProjectionId: projectionId}, &projectionKey)
+	if err != nil {
+		if isSvcUnavailable(err) {
+			return nil, fmt.Errorf("no key is configured for projection %s and the reactor doesn't publish projection keys", projectionId)
+		}
+		return nil, fmt.Errorf("failed to fetch key for projection %s: %w", projectionId, err)
+	}
+	if projectionKey.Alg != SealedSecretAlg {
+		return nil, fmt.Errorf("unsupported alg %q for projection %s", projectionKey.Alg, projectionId)
+	}
+
+	key, err := ParseProjectionKey(projectionKey.KeyId, projectionKey.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key published for projection %s: %w", projectionId, err)
+	}
+
+	tflog.Debug(ctx, fmt.Sprintf("Fetched key %s for projection %s", key.KeyId, projectionId))
+	c.projectionKeys.Store(projectionId, key)
+	return key, nil
+}
+
+// SealSecretVars seals each of the secret vars for the appliance of a projection.
+func (c *ReactorClient) SealSecretVars(ctx context.Context, projectionId string, secretVars map[string]string) (map[string]TfSealedSecret, error) {
+	if len(secretVars) == 0 {
+		return nil, nil
+	}
+
+	key, err := c.ProjectionKey(ctx, projectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make(map[string]TfSealedSecret)
+	for name, value := range secretVars {
+		sealedSecret, err := SealSecret(key, projectionId, name, value)
+		if err != nil {
+			return nil, err
+		}
+		sealed[name] = *sealedSecret
+	}
+	return sealed, nil
+}
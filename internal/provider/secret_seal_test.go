@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testProjectionKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+	return privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestSealSecret(t *testing.T) {
+	privateKey, publicKeyPem := testProjectionKey(t)
+	projectionId := "0000000000000000:0000000000000000:0000000000000000"
+
+	key, err := ParseProjectionKey("", publicKeyPem)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(key.KeyId, "sha256:") {
+		t.Errorf("expected a fingerprint key id, got %s", key.KeyId)
+	}
+
+	sealed, err := SealSecret(key, projectionId, "password", "hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(string(sealed.Ciphertext), "hunter2") {
+		t.Errorf("expected the secret to be encrypted")
+	}
+
+	opened, err := OpenSecret(privateKey, projectionId, "password", sealed)
+	if err != nil || opened != "hunter2" {
+		t.Fatalf("expected hunter2, got %q, %v", opened, err)
+	}
+
+	if _, err := OpenSecret(privateKey, projectionId, "token", sealed); err == nil {
+		t.Errorf("expected a secret sealed for one var not to open as another")
+	}
+
+	tampered := *sealed
+	tampered.Ciphertext = append([]byte{}, sealed.Ciphertext...)
+	tampered.Ciphertext[0] ^= 0xff
+	if _, err := OpenSecret(privateKey, projectionId, "password", &tampered); err == nil {
+		t.Errorf("expected a tampered secret not to open")
+	}
+
+	otherKey, _ := testProjectionKey(t)
+	if _, err := OpenSecret(otherKey, projectionId, "password", sealed); err == nil {
+		t.Errorf("expected a secret not to open with another key")
+	}
+}
+
+func TestReactorClientProjectionKey(t *testing.T) {
+	privateKey, publicKeyPem := testProjectionKey(t)
+	_, localPublicKeyPem := testProjectionKey(t)
+
+	var keyReqs int
+	reactor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/stack/tf/keys" {
+			http.NotFound(w, r)
+			return
+		}
+		keyReqs++
+		var req TfProjectionKeyReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "failed to unmarshal key req", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(TfProjectionKey{
+			ProjectionId: req.ProjectionId,
+			KeyId:        "key_1",
+			Alg:          SealedSecretAlg,
+			PublicKey:    publicKeyPem,
+		})
+	}))
+	defer reactor.Close()
+
+	client := NewReactorClient(http.DefaultClient, reactor.URL, "deadbeef", ReactorClientOpts{
+		ProjectionKeys: map[string]string{"local": localPublicKeyPem},
+	})
+
+	for i := 0; i < 2; i++ {
+		sealed, err := client.SealSecretVars(context.Background(), "remote", map[string]string{"password": "hunter2"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		secret := sealed["password"]
+		if secret.KeyId != "key_1" {
+			t.Errorf("expected key_1, got %s", secret.KeyId)
+		}
+		if opened, err := OpenSecret(privateKey, "remote", "password", &secret); err != nil || opened != "hunter2" {
+			t.Errorf("expected hunter2, got %q, %v", opened, err)
+		}
+	}
+	if keyReqs != 1 {
+		t.Errorf("expected the key to be fetched once, got %d fetches", keyReqs)
+	}
+
+	if _, err := client.SealSecretVars(context.Background(), "local", map[string]string{"password": "hunter2"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if keyReqs != 1 {
+		t.Errorf("expected locally configured keys not to be fetched")
+	}
+}
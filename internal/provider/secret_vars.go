@@ -0,0 +1,305 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// The value that Secret-typed vars and outputs are replaced with in logs.
+const redactedValue = "(sensitive)"
+
+// The size of the salts that secret vars are hashed with.
+const secretVarsSaltSize = 16
+
+// secretVarsHash hashes secret vars so that changes to them can be detected without keeping them in state. The hash is
+// an HMAC keyed with a salt of the twin rsx, kept with the hash as salt:hmac in hex, so that the same secrets hash
+// differently for each twin rsx and can't be guessed by hashing candidates once for all of them.
+func secretVarsHash(secretVars map[string]string, salt []byte) string {
+	mac := hmac.New(sha256.New, salt)
+	for _, k := range slices.Sorted(maps.Keys(secretVars)) {
+		_, _ = fmt.Fprintf(mac, "%q=%q\n", k, secretVars[k])
+	}
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSecretVarsSalt returns a random salt for hashing the secret vars of a twin rsx that has none yet.
+func newSecretVarsSalt() ([]byte, error) {
+	salt := make([]byte, secretVarsSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// secretVarsSalt returns the salt that a secret_vars_hash was hashed with, or nil if it isn't known.
+func secretVarsSalt(hash types.String) []byte {
+	if hash.IsNull() || hash.IsUnknown() {
+		return nil
+	}
+	saltHex, _, ok := strings.Cut(hash.ValueString(), ":")
+	if !ok {
+		return nil
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil || len(salt) == 0 {
+		return nil
+	}
+	return salt
+}
+
+// secretVarsHashModifier plans secret_vars_hash from the write-only secret_vars in the config, so that changing a
+// secret var plans an update.
+type secretVarsHashModifier struct{}
+
+var _ planmodifier.String = secretVarsHashModifier{}
+
+func (m secretVarsHashModifier) Description(ctx context.Context) string {
+	return m.MarkdownDescription(ctx)
+}
+
+func (m secretVarsHashModifier) MarkdownDescription(_ context.Context) string {
+	return "Set to the hash of `secret_vars`"
+}
+
+func (m secretVarsHashModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to plan when the resource is being destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var secretVars types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secret_vars"), &secretVars)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.PlanValue = plannedSecretVarsHash(secretVars, req.StateValue)
+}
+
+// plannedSecretVarsHash returns the secret_vars_hash that the configured secret_vars plan, hashed with the salt of the
+// prior secret_vars_hash. Twin rsxs without a salt yet are salted when applied, so their hash isn't known until then.
+func plannedSecretVarsHash(secretVars types.Map, prior types.String) types.String {
+	salt := secretVarsSalt(prior)
+	switch {
+	case secretVars.IsNull() || len(secretVars.Elements()) == 0:
+		return types.StringNull()
+	case secretVars.IsUnknown() || hasUnknownElements(secretVars) || salt == nil:
+		return types.StringUnknown()
+	default:
+		return types.StringValue(secretVarsHash(mapToStringMap(secretVars), salt))
+	}
+}
+
+func hasUnknownElements(m types.Map) bool {
+	for _, v := range m.Elements() {
+		if v.IsUnknown() {
+			return true
+		}
+	}
+	return false
+}
+
+// secretVarsValidator validates that Secret and sensitive vars are passed through secret_vars, and only those.
+type secretVarsValidator struct{}
+
+var _ resource.ConfigValidator = secretVarsValidator{}
+
+func (v secretVarsValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v secretVarsValidator) MarkdownDescription(_ context.Context) string {
+	return "vars declared as `Secret` or `sensitive` in `schema` must be passed in `secret_vars` rather than `vars`"
+}
+
+func (v secretVarsValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var vars types.Dynamic
+	var secretVars, schema types.Map
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("vars"), &vars)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("secret_vars"), &secretVars)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if resp.Diagnostics.HasError() || schema.IsNull() || schema.IsUnknown() {
+		return
+	}
+
+	props := schematize(schema)
+
+	if varElems, known, err := varsElements(vars); err == nil && known {
+		for _, k := range slices.Sorted(maps.Keys(varElems)) {
+			if props.IsSecret(k) {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("vars").AtMapKey(k),
+					"Secret Variable In vars",
+					fmt.Sprintf("Variable %q is declared as %s in schema; pass it in secret_vars so that it isn't shown in plans or stored in state.", k, secretness(props[k])),
+				)
+			}
+		}
+	}
+
+	if !secretVars.IsNull() && !secretVars.IsUnknown() {
+		declared := schema.Elements()
+		elems := secretVars.Elements()
+		for _, k := range slices.Sorted(maps.Keys(elems)) {
+			if _, ok := declared[k]; !ok {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("secret_vars").AtMapKey(k),
+					"Undeclared Variable",
+					fmt.Sprintf("Variable %q is not declared in schema; declare it as Secret in schema to pass it to the template.", k),
+				)
+				continue
+			}
+			prop, ok := props[k]
+			if !ok {
+				// The property isn't known yet.
+				continue
+			}
+			if !prop.IsSecret() {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("secret_vars").AtMapKey(k),
+					"Non-Secret Variable In secret_vars",
+					fmt.Sprintf("Variable %q is declared as %s in schema; only Secret or sensitive vars can be passed in secret_vars.", k, prop.Type),
+				)
+				continue
+			}
+			value, ok := knownString(elems[k])
+			if !ok || !prop.Type.IsValid() || prop.checkDecl() != nil {
+				continue
+			}
+			if err := prop.Check(value); err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("secret_vars").AtMapKey(k),
+					"Invalid Variable Value",
+					fmt.Sprintf("Variable %q is declared as %s in schema, but %s.", k, prop.Type, err),
+				)
+			}
+		}
+	}
+}
+
+// secretness describes why a property is secret, for diagnostics.
+func secretness(prop TfRsxProp) string {
+	if prop.Type == Secret {
+		return "Secret"
+	}
+	return "sensitive"
+}
+
+// withoutSecrets returns the entries of m whose keys the schema doesn't declare as Secret or sensitive.
+func withoutSecrets(m map[string]string, props TfRsxProps) map[string]string {
+	result := make(map[string]string)
+	for k, v := range m {
+		if !props.IsSecret(k) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// redactSecrets returns a copy of m with the values of the entries the schema declares as Secret or sensitive masked.
+func redactSecrets(m *map[string]string, props TfRsxProps) *map[string]string {
+	if m == nil {
+		return m
+	}
+	result := make(map[string]string)
+	for k, v := range *m {
+		if props.IsSecret(k) {
+			v = redactedValue
+		}
+		result[k] = v
+	}
+	return &result
+}
+
+// redacted returns a copy of the twin rsx with its Secret and sensitive vars and outputs masked, fit for logging.
+func (t *TfLoFiTwinRsx) redacted() *TfLoFiTwinRsx {
+	if t == nil {
+		return nil
+	}
+	result := *t
+	props := t.props(nil)
+	result.Vars = redactSecrets(t.Vars, props)
+	result.Outputs = redactSecrets(t.Outputs, props)
+	return &result
+}
+
+func (e TfRsxEvt) redacted() any {
+	e.ApiKey = redactedValue
+	e.LoFiTwinRsx = e.LoFiTwinRsx.redacted()
+	return e
+}
+
+func (e TfRsxEvtResult) redacted() any {
+	if e.LoFiTwinRsx != nil {
+		e.LoFiTwinRsx = &Delta[TfLoFiTwinRsx]{
+			Before: e.LoFiTwinRsx.Before.redacted(),
+			After:  e.LoFiTwinRsx.After.redacted(),
+		}
+	}
+	return e
+}
+
+func (b TfRsxEvtBatch) redacted() any {
+	b.ApiKey = redactedValue
+	evts := make([]*TfRsxEvt, len(b.Evts))
+	for i, evt := range b.Evts {
+		redactedEvt := evt.redacted().(TfRsxEvt)
+		evts[i] = &redactedEvt
+	}
+	b.Evts = evts
+	return b
+}
+
+func (b TfRsxEvtBatchResult) redacted() any {
+	results := make([]TfRsxEvtBatchItemResult, len(b.Results))
+	for i, result := range b.Results {
+		if result.Result != nil {
+			redactedResult := result.Result.redacted().(TfRsxEvtResult)
+			result.Result = &redactedResult
+		}
+		results[i] = result
+	}
+	b.Results = results
+	return b
+}
+
+func (b TfRsxBulkReadResult) redacted() any {
+	rsxs := make(map[string]*TfLoFiTwinRsx)
+	for k, rsx := range b.LoFiTwinRsxs {
+		rsxs[k] = rsx.redacted()
+	}
+	b.LoFiTwinRsxs = rsxs
+	return b
+}
+
+// redactable is implemented by the messages exchanged with the reactor that can carry secrets.
+type redactable interface {
+	redacted() any
+}
+
+// redactedJson renders v as JSON for logging, with any secrets it carries masked.
+func redactedJson(v any) string {
+	if r, ok := v.(redactable); ok {
+		v = r.redacted()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("(unrenderable %T: %s)", v, err)
+	}
+	return string(b)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestSecretVarsValidator(t *testing.T) {
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+		"vars":        testStringMap(map[string]string{"name": "x", "password": "hunter2"}),
+		"secret_vars": testStringMap(map[string]string{"token": "t0k3n", "size": "3", "other": "y"}),
+		"schema":      testSchema(map[string]TfRsxPropType{"name": Str, "password": Secret, "token": Secret, "size": I32}),
+	})
+
+	resp := &fwresource.ValidateConfigResponse{}
+	secretVarsValidator{}.ValidateResource(context.Background(), fwresource.ValidateConfigRequest{Config: config}, resp)
+
+	expected := map[string]string{
+		path.Root("vars").AtMapKey("password").String():     "Secret Variable In vars",
+		path.Root("secret_vars").AtMapKey("size").String():  "Non-Secret Variable In secret_vars",
+		path.Root("secret_vars").AtMapKey("other").String(): "Undeclared Variable",
+	}
+	if resp.Diagnostics.ErrorsCount() != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), resp.Diagnostics)
+	}
+	for _, d := range resp.Diagnostics.Errors() {
+		p := d.(diag.DiagnosticWithPath).Path().String()
+		if expected[p] != d.Summary() {
+			t.Errorf("unexpected error at %s: %s", p, d.Summary())
+		}
+	}
+}
+
+func TestSecretVarsHash(t *testing.T) {
+	secretVars := map[string]string{"password": "hunter2", "token": "t0k3n"}
+
+	saltA, err := newSecretVarsSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltB, err := newSecretVarsSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashA, hashB := secretVarsHash(secretVars, saltA), secretVarsHash(secretVars, saltB)
+	if hashA == hashB {
+		t.Errorf("expected the same secret vars of different twin rsxs to hash differently, got %s", hashA)
+	}
+	if strings.Contains(hashA, "hunter2") {
+		t.Errorf("expected the hash not to reveal the secret vars, got %s", hashA)
+	}
+
+	// Plans hash with the salt of the prior hash, so unchanged secret vars plan the prior hash.
+	secretVarsMap := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"password": types.StringValue("hunter2"),
+		"token":    types.StringValue("t0k3n"),
+	})
+	if planned := plannedSecretVarsHash(secretVarsMap, types.StringValue(hashA)); !planned.Equal(types.StringValue(hashA)) {
+		t.Errorf("expected unchanged secret vars to plan %s, got %s", hashA, planned)
+	}
+	changed := types.MapValueMust(types.StringType, map[string]attr.Value{"password": types.StringValue("hunter3")})
+	if planned := plannedSecretVarsHash(changed, types.StringValue(hashA)); planned.IsUnknown() || planned.Equal(types.StringValue(hashA)) {
+		t.Errorf("expected changed secret vars to plan a new hash, got %s", planned)
+	}
+	if planned := plannedSecretVarsHash(secretVarsMap, types.StringNull()); !planned.IsUnknown() {
+		t.Errorf("expected secret vars without a salt to be hashed when applied, got %s", planned)
+	}
+}
+
+func TestRedactedJson(t *testing.T) {
+	vars := map[string]string{"name": "x", "password": "hunter2"}
+	outputs := map[string]string{"url": "https://example.com", "token": "t0k3n"}
+	schema := map[string]TfRsxPropType{"name": Str, "password": Secret, "url": Str, "token": Secret}
+	twin := &TfLoFiTwinRsx{Vars: &vars, Outputs: &outputs, Schema: &schema}
+
+	logged := redactedJson(&TfRsxEvtResult{LoFiTwinRsx: &Delta[TfLoFiTwinRsx]{Before: twin, After: twin}})
+	for _, secret := range []string{"hunter2", "t0k3n"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("expected %s to be redacted from %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "https://example.com") {
+		t.Errorf("expected non-secret outputs to be logged, got %s", logged)
+	}
+	if vars["password"] != "hunter2" {
+		t.Errorf("redaction must not modify the original")
+	}
+
+	logged = redactedJson(&TfRsxEvt{ApiKey: "deadbeef", LoFiTwinRsx: twin})
+	if strings.Contains(logged, "deadbeef") || strings.Contains(logged, "hunter2") {
+		t.Errorf("expected the api key and secrets to be redacted from %s", logged)
+	}
+}
+
+func TestLoFiTwinRsxSecretVars(t *testing.T) {
+	reactor := newFakeReactor()
+	defer reactor.Close()
+
+	config := func(password string) string {
+		return fmt.Sprintf(`
+provider "tensor9" {
+  endpoint = %[1]q
+  api_key  = "deadbeef"
+}
+resource "tensor9_lofi_twin" "test_twin" {
+  template      = "{}"
+  template_fmt  = "TerraformJson"
+  projection_id = "0000000000000000:0000000000000000:0000000000000000"
+  rsx_id        = "rsx_a"
+  vars          = { name = "x" }
+  secret_vars   = { password = %[2]q }
+  schema        = { name = { type = "Str" }, password = { type = "Secret" } }
+}
+`, reactor.URL, password)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			// Write-only attributes
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config("hunter2"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("vars"),
+						knownvalue.MapExact(map[string]knownvalue.Check{"name": knownvalue.StringExact("x")}),
+					),
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("secret_vars"),
+						knownvalue.Null(),
+					),
+					statecheck.ExpectKnownValue(
+						"tensor9_lofi_twin.test_twin",
+						tfjsonpath.New("secret_vars_hash"),
+						knownvalue.StringRegexp(regexp.MustCompile(`^[0-9a-f]{32}:[0-9a-f]{64}$`)),
+					),
+				},
+				Check: func(s *terraform.State) error {
+					reactor.mu.Lock()
+					defer reactor.mu.Unlock()
+
+					infraId := s.RootModule().Resources["tensor9_lofi_twin.test_twin"].Primary.ID
+					if reactor.twins[infraId].Vars != nil && (*reactor.twins[infraId].Vars)["password"] != "" {
+						return fmt.Errorf("expected the secret var not to be sent in plaintext")
+					}
+					if reactor.secrets[infraId]["password"] != "hunter2" {
+						return fmt.Errorf("expected the appliance to decrypt the secret var, got %v", reactor.secrets[infraId])
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
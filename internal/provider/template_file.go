@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// templateSha256 hashes a template, as the reactor stores templates by.
+func templateSha256(template string) string {
+	sum := sha256.Sum256([]byte(template))
+	return hex.EncodeToString(sum[:])
+}
+
+// readTemplateFile reads the template of a twin rsx from its template_file, reporting failures at template_file.
+func readTemplateFile(templateFile types.String) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	b, err := os.ReadFile(templateFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("template_file"), "Unreadable Template File", fmt.Sprintf("Unable to read the template, got error: %s", err))
+		return "", diags
+	}
+	return string(b), diags
+}
+
+// configuredTemplate returns the template of a twin rsx from either template or template_file, and whether it's known
+// yet.
+func configuredTemplate(template TemplateValue, templateFile types.String) (string, bool, diag.Diagnostics) {
+	switch {
+	case !templateFile.IsNull():
+		if templateFile.IsUnknown() {
+			return "", false, nil
+		}
+		raw, diags := readTemplateFile(templateFile)
+		return raw, !diags.HasError(), diags
+	case template.IsUnknown():
+		return "", false, nil
+	default:
+		return template.ValueString(), !template.IsNull(), nil
+	}
+}
+
+// templateHashModifier plans template_hash from the contents of template_file, so that changing the file plans an
+// update without keeping its contents in state.
+type templateHashModifier struct{}
+
+var _ planmodifier.String = templateHashModifier{}
+
+func (m templateHashModifier) Description(ctx context.Context) string {
+	return m.MarkdownDescription(ctx)
+}
+
+func (m templateHashModifier) MarkdownDescription(_ context.Context) string {
+	return "Set to the hash of the contents of `template_file`"
+}
+
+func (m templateHashModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to plan when the resource is being destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var templateFile types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template_file"), &templateFile)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planValue, diags := plannedTemplateHash(templateFile)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.PlanValue = planValue
+}
+
+// plannedTemplateHash returns the template_hash that the configured template_file plans.
+func plannedTemplateHash(templateFile types.String) (types.String, diag.Diagnostics) {
+	switch {
+	case templateFile.IsNull():
+		return types.StringNull(), nil
+	case templateFile.IsUnknown():
+		return types.StringUnknown(), nil
+	default:
+		raw, diags := readTemplateFile(templateFile)
+		if diags.HasError() {
+			return types.StringUnknown(), diags
+		}
+		return types.StringValue(templateSha256(raw)), diags
+	}
+}
+
+// templateSourceValidator validates that exactly one of template and template_file is set.
+type templateSourceValidator struct{}
+
+var _ resource.ConfigValidator = templateSourceValidator{}
+
+func (v templateSourceValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v templateSourceValidator) MarkdownDescription(_ context.Context) string {
+	return "exactly one of `template` and `template_file` must be set"
+}
+
+func (v templateSourceValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var template TemplateValue
+	var templateFile types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template"), &template)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template_file"), &templateFile)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !template.IsNull() && !templateFile.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("template_file"),
+			"Conflicting Template",
+			"Only one of template and template_file may be set.",
+		)
+	case template.IsNull() && templateFile.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("template"),
+			"Missing Template",
+			"One of template and template_file must be set.",
+		)
+	}
+}
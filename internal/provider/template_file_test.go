@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTemplateSourceValidator(t *testing.T) {
+	template := tftypes.NewValue(tftypes.String, "{}")
+	templateFile := tftypes.NewValue(tftypes.String, "template.tf.json")
+	cases := map[string]struct {
+		attrs    map[string]tftypes.Value
+		expected string
+	}{
+		"template":      {map[string]tftypes.Value{"template": template}, ""},
+		"template_file": {map[string]tftypes.Value{"template_file": templateFile}, ""},
+		"both":          {map[string]tftypes.Value{"template": template, "template_file": templateFile}, "Conflicting Template"},
+		"neither":       {map[string]tftypes.Value{}, "Missing Template"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := &resource.ValidateConfigResponse{}
+			templateSourceValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: testLoFiTwinConfig(t, c.attrs)}, resp)
+
+			if c.expected == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.ErrorsCount() != 1 || resp.Diagnostics.Errors()[0].Summary() != c.expected {
+				t.Errorf("expected a %q error, got %v", c.expected, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestTemplateValidatorTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	valid, invalid := filepath.Join(dir, "valid.tf"), filepath.Join(dir, "invalid.tf")
+	if err := os.WriteFile(valid, []byte(`variable "name" {}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte(`variable "name" {`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		valid:                            "",
+		invalid:                          "Invalid Template",
+		filepath.Join(dir, "missing.tf"): "Unreadable Template File",
+	}
+	for file, expected := range cases {
+		config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+			"template_file": tftypes.NewValue(tftypes.String, file),
+			"template_fmt":  tftypes.NewValue(tftypes.String, string(TerraformFmt)),
+			"vars":          testStringMap(map[string]string{"name": "x"}),
+			"schema":        testSchema(map[string]TfRsxPropType{"name": Str}),
+		})
+
+		resp := &resource.ValidateConfigResponse{}
+		templateValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+		if expected == "" {
+			if resp.Diagnostics.HasError() {
+				t.Errorf("%s: unexpected diagnostics: %v", file, resp.Diagnostics)
+			}
+			continue
+		}
+		if resp.Diagnostics.ErrorsCount() != 1 {
+			t.Fatalf("%s: expected 1 error, got %v", file, resp.Diagnostics)
+		}
+		d := resp.Diagnostics.Errors()[0]
+		if d.Summary() != expected || !d.(diag.DiagnosticWithPath).Path().Equal(path.Root("template_file")) {
+			t.Errorf("%s: expected a %q error at template_file, got %v", file, expected, d)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TfTemplateFmt is the format of a twin rsx's template.
+type TfTemplateFmt string
+
+const (
+	TerraformFmt          TfTemplateFmt = "Terraform"
+	TerraformJsonFmt      TfTemplateFmt = "TerraformJson"
+	CloudFormationJsonFmt TfTemplateFmt = "CloudFormationJson"
+	CloudFormationYamlFmt TfTemplateFmt = "CloudFormationYaml"
+	KubernetesYamlFmt     TfTemplateFmt = "KubernetesYaml"
+)
+
+// templateFmt parses the templates of a TfTemplateFmt, so that broken templates are caught before they're sent to the
+// appliance.
+type templateFmt interface {
+	// Parse parses a template, failing on syntax errors and on missing or unknown top level keys.
+	Parse(template string) (*parsedTemplate, error)
+}
+
+// parsedTemplate is what the provider needs to know of a template to check it against the vars and schema of its
+// twin rsx.
+type parsedTemplate struct {
+	// Params are the vars that the template declares, by name.
+	Params map[string]templateParam
+	// Refs are the names of the vars that the template references, sorted.
+	Refs []string
+	// Outputs are the names of the outputs that the template declares, sorted.
+	Outputs []string
+	// Objects are the identities of the objects that the template creates, sorted, for formats whose outputs are
+	// extracted from its objects rather than declared, or nil for other formats.
+	Objects []string
+}
+
+type templateParam struct {
+	// HasDefault is set if the template defaults the var when it isn't passed.
+	HasDefault bool
+	// Type is the type that the template declares the var as, in the template's own terms, if it declares one.
+	Type string
+	// PropTypes are the property types that the var may be declared as in schema, or nil if it may be of any type.
+	PropTypes []TfRsxPropType
+	// Sensitive is set if the template treats the var as sensitive, so it should be passed in secret_vars.
+	Sensitive bool
+}
+
+func newParsedTemplate() *parsedTemplate {
+	return &parsedTemplate{Params: make(map[string]templateParam)}
+}
+
+func (p *parsedTemplate) addRef(name string) {
+	if i, found := slices.BinarySearch(p.Refs, name); !found {
+		p.Refs = slices.Insert(p.Refs, i, name)
+	}
+}
+
+func (p *parsedTemplate) addOutput(name string) {
+	if i, found := slices.BinarySearch(p.Outputs, name); !found {
+		p.Outputs = slices.Insert(p.Outputs, i, name)
+	}
+}
+
+// addObject adds the identity of an object, reporting false if the template already creates it.
+func (p *parsedTemplate) addObject(id string) bool {
+	i, found := slices.BinarySearch(p.Objects, id)
+	if !found {
+		p.Objects = slices.Insert(p.Objects, i, id)
+	}
+	return !found
+}
+
+// templateFmts holds the parser of each known TfTemplateFmt.
+var templateFmts = map[TfTemplateFmt]templateFmt{
+	TerraformFmt:          terraformFmt{},
+	TerraformJsonFmt:      terraformJsonFmt{},
+	CloudFormationJsonFmt: cloudFormationFmt{},
+	CloudFormationYamlFmt: cloudFormationFmt{yaml: true},
+	KubernetesYamlFmt:     kubernetesFmt{},
+}
+
+// TfTemplateFmts lists the known TfTemplateFmts, sorted.
+func TfTemplateFmts() []TfTemplateFmt {
+	return slices.Sorted(maps.Keys(templateFmts))
+}
+
+func templateFmtsMarkdown() string {
+	var names []string
+	for _, templateFmt := range TfTemplateFmts() {
+		names = append(names, "`"+string(templateFmt)+"`")
+	}
+	return strings.Join(names, ", ")
+}
+
+// The top level blocks of Terraform configurations.
+var terraformBlockTypes = []string{"check", "data", "import", "locals", "module", "moved", "output", "provider", "removed", "resource", "terraform", "variable"}
+
+// terraformFmt parses Terraform templates written in HCL.
+type terraformFmt struct{}
+
+func (f terraformFmt) Parse(template string) (*parsedTemplate, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(template), "template.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	if names := slices.Sorted(maps.Keys(body.Attributes)); len(names) > 0 {
+		attr := body.Attributes[names[0]]
+		return nil, fmt.Errorf("%s: unexpected argument %q; Terraform templates only hold blocks at the top level", attr.SrcRange, attr.Name)
+	}
+
+	parsed := newParsedTemplate()
+	for _, block := range body.Blocks {
+		if !slices.Contains(terraformBlockTypes, block.Type) {
+			return nil, fmt.Errorf("%s: unsupported block type %q", block.TypeRange, block.Type)
+		}
+		switch block.Type {
+		case "variable":
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("%s: variable blocks must have a name", block.TypeRange)
+			}
+			_, hasDefault := block.Body.Attributes["default"]
+			parsed.Params[block.Labels[0]] = templateParam{HasDefault: hasDefault}
+		case "output":
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("%s: output blocks must have a name", block.TypeRange)
+			}
+			parsed.addOutput(block.Labels[0])
+		}
+	}
+
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		if expr, ok := node.(hclsyntax.Expression); ok {
+			addVarRefs(parsed, expr)
+		}
+		return nil
+	})
+	return parsed, nil
+}
+
+// terraformJsonFmt parses Terraform templates written in JSON, in which expressions are embedded in strings as
+// "${...}" templates.
+type terraformJsonFmt struct{}
+
+func (f terraformJsonFmt) Parse(template string) (*parsedTemplate, error) {
+	v, err := decodeJson(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	root, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object, got %s", jsonKind(v))
+	}
+
+	parsed := newParsedTemplate()
+	for _, key := range slices.Sorted(maps.Keys(root)) {
+		if !slices.Contains(terraformBlockTypes, key) && key != "//" {
+			return nil, fmt.Errorf("unsupported top level key %q", key)
+		}
+	}
+
+	if variables, ok := root["variable"]; ok {
+		decls, ok := variables.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("variable: expected an object of variables, got %s", jsonKind(variables))
+		}
+		for name, decl := range decls {
+			declObj, ok := decl.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("variable.%s: expected an object, got %s", name, jsonKind(decl))
+			}
+			_, hasDefault := declObj["default"]
+			parsed.Params[name] = templateParam{HasDefault: hasDefault}
+		}
+	}
+	if outputs, ok := root["output"]; ok {
+		decls, ok := outputs.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("output: expected an object of outputs, got %s", jsonKind(outputs))
+		}
+		for name := range decls {
+			parsed.addOutput(name)
+		}
+	}
+
+	if err := walkJsonStrings(root, "", func(at string, s string) error {
+		if !strings.Contains(s, "${") {
+			return nil
+		}
+		expr, diags := hclsyntax.ParseTemplate([]byte(s), "template.tf.json", hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("%s: %s", at, diags.Error())
+		}
+		addVarRefs(parsed, expr)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// addVarRefs adds the vars that a Terraform expression references as var.<name>.
+func addVarRefs(parsed *parsedTemplate, expr hclsyntax.Expression) {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+			parsed.addRef(attr.Name)
+		}
+	}
+}
+
+// walkJsonStrings calls f with every string value in a decoded JSON value, along with its path.
+func walkJsonStrings(v any, at string, f func(at string, s string) error) error {
+	switch v := v.(type) {
+	case string:
+		return f(at, v)
+	case []any:
+		for i, item := range v {
+			if err := walkJsonStrings(item, fmt.Sprintf("%s[%d]", at, i), f); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			itemAt := k
+			if at != "" {
+				itemAt = at + "." + k
+			}
+			if err := walkJsonStrings(v[k], itemAt, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseTemplate parses a template of the given format.
+func parseTemplate(templateFmt TfTemplateFmt, template string) (*parsedTemplate, error) {
+	parser, ok := templateFmts[templateFmt]
+	if !ok {
+		return nil, fmt.Errorf("unknown template format %q", templateFmt)
+	}
+	return parser.Parse(template)
+}
+
+// templateFmtValidator validates that template_fmt is a known TfTemplateFmt.
+type templateFmtValidator struct{}
+
+var _ validator.String = templateFmtValidator{}
+
+func (v templateFmtValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v templateFmtValidator) MarkdownDescription(_ context.Context) string {
+	return "must be one of " + templateFmtsMarkdown()
+}
+
+func (v templateFmtValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	templateFmt := TfTemplateFmt(req.ConfigValue.ValueString())
+	if _, ok := templateFmts[templateFmt]; ok {
+		return
+	}
+
+	detail := fmt.Sprintf("%q is not a template format; expected one of %s.", templateFmt, templateFmtsMarkdown())
+	if closest, ok := closestTemplateFmt(string(templateFmt)); ok {
+		detail = fmt.Sprintf("%q is not a template format; did you mean %q? Expected one of %s.", templateFmt, closest, templateFmtsMarkdown())
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Invalid Template Format", detail)
+}
+
+// closestTemplateFmt suggests the TfTemplateFmt that the given misspelt format most likely meant, if any is close
+// enough.
+func closestTemplateFmt(s string) (TfTemplateFmt, bool) {
+	var closest TfTemplateFmt
+	closestDist := -1
+	for _, templateFmt := range TfTemplateFmts() {
+		dist := editDistance(strings.ToLower(s), strings.ToLower(string(templateFmt)))
+		if closestDist < 0 || dist < closestDist {
+			closest, closestDist = templateFmt, dist
+		}
+	}
+	if closestDist > len(s)/2+1 {
+		return "", false
+	}
+	return closest, true
+}
+
+// templateValidator validates that the template parses as its template_fmt, and that the vars it references are
+// passed to it.
+type templateValidator struct{}
+
+var _ resource.ConfigValidator = templateValidator{}
+
+func (v templateValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v templateValidator) MarkdownDescription(_ context.Context) string {
+	return "`template` must be a valid template of its `template_fmt`, and the vars it references must be passed in `vars` or `secret_vars`"
+}
+
+func (v templateValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var template TemplateValue
+	var templateFile, templateFmt types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template"), &template)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template_file"), &templateFile)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("template_fmt"), &templateFmt)...)
+	if resp.Diagnostics.HasError() || templateFmt.IsNull() || templateFmt.IsUnknown() {
+		return
+	}
+	if _, ok := templateFmts[TfTemplateFmt(templateFmt.ValueString())]; !ok {
+		// Reported by templateFmtValidator.
+		return
+	}
+	if !template.IsNull() && !templateFile.IsNull() {
+		// Reported by templateSourceValidator.
+		return
+	}
+
+	raw, known, diags := configuredTemplate(template, templateFile)
+	resp.Diagnostics.Append(diags...)
+	if !known {
+		return
+	}
+	// Problems with the template are reported at whichever attribute it's set by.
+	templatePath := path.Root("template")
+	if !templateFile.IsNull() {
+		templatePath = path.Root("template_file")
+	}
+
+	parsed, err := parseTemplate(TfTemplateFmt(templateFmt.ValueString()), raw)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			templatePath,
+			"Invalid Template",
+			fmt.Sprintf("The template is not a valid %s template: %s", templateFmt.ValueString(), err),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(checkTemplateVars(ctx, req, templatePath, parsed)...)
+	resp.Diagnostics.Append(checkTemplateOutputs(ctx, req, TfTemplateFmt(templateFmt.ValueString()), parsed)...)
+}
+
+// checkTemplateVars checks the vars that a parsed template declares and references against the vars, secret_vars and
+// schema of its twin rsx.
+func checkTemplateVars(ctx context.Context, req resource.ValidateConfigRequest, templatePath path.Path, parsed *parsedTemplate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var vars types.Dynamic
+	var secretVars, schema types.Map
+	diags.Append(req.Config.GetAttribute(ctx, path.Root("vars"), &vars)...)
+	diags.Append(req.Config.GetAttribute(ctx, path.Root("secret_vars"), &secretVars)...)
+	diags.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if diags.HasError() || secretVars.IsUnknown() || schema.IsUnknown() {
+		return diags
+	}
+	varElems, known, err := varsElements(vars)
+	if err != nil || !known {
+		return diags
+	}
+
+	for _, ref := range parsed.Refs {
+		if _, ok := parsed.Params[ref]; !ok {
+			diags.AddAttributeError(
+				templatePath,
+				"Undeclared Template Variable",
+				fmt.Sprintf("The template references variable %q, but doesn't declare it.", ref),
+			)
+		}
+	}
+
+	props := schematize(schema)
+	passed := func(k string) bool {
+		if v, ok := varElems[k]; ok && !v.IsNull() {
+			return true
+		}
+		if v, ok := secretVars.Elements()[k]; ok && !v.IsNull() {
+			return true
+		}
+		return props[k].Default != nil
+	}
+	for _, k := range slices.Sorted(maps.Keys(parsed.Params)) {
+		param := parsed.Params[k]
+		if !param.HasDefault && !passed(k) {
+			diags.AddAttributeError(
+				path.Root("vars"),
+				"Missing Template Variable",
+				fmt.Sprintf("The template declares variable %q without a default, but it isn't passed in vars or secret_vars, "+
+					"nor defaulted in schema.", k),
+			)
+		}
+		if param.PropTypes != nil && !slices.Contains(param.PropTypes, props.TypeOf(k)) {
+			diags.AddAttributeError(
+				path.Root("schema").AtMapKey(k),
+				"Incompatible Variable Type",
+				fmt.Sprintf("The template declares variable %q as a %s, which can't be passed a %s; declare it in schema as one of %s.",
+					k, param.Type, props.TypeOf(k), propTypeList(param.PropTypes)),
+			)
+		}
+		if v, ok := varElems[k]; ok && !v.IsNull() && param.Sensitive {
+			diags.AddAttributeWarning(
+				path.Root("vars"),
+				"Sensitive Variable In vars",
+				fmt.Sprintf("The template declares variable %q as sensitive, but it's passed in vars, which is kept in state "+
+					"and shown in plans; pass it in secret_vars instead.", k),
+			)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(varElems)) {
+		if _, ok := parsed.Params[k]; !ok {
+			diags.AddAttributeWarning(
+				path.Root("vars"),
+				"Unused Variable",
+				fmt.Sprintf("Variable %q is passed in vars, but the template doesn't declare it.", k),
+			)
+		}
+	}
+
+	return diags
+}
+
+// checkTemplateOutputs checks the outputs that a parsed template declares, or the objects that their outputs are
+// extracted from, against the schema of its twin rsx.
+func checkTemplateOutputs(ctx context.Context, req resource.ValidateConfigRequest, templateFmt TfTemplateFmt, parsed *parsedTemplate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var schema types.Map
+	diags.Append(req.Config.GetAttribute(ctx, path.Root("schema"), &schema)...)
+	if diags.HasError() || schema.IsUnknown() {
+		return diags
+	}
+	props := schematize(schema)
+
+	for _, k := range parsed.Outputs {
+		if _, ok := props[k]; !ok {
+			diags.AddAttributeWarning(
+				path.Root("schema"),
+				"Undeclared Template Output",
+				fmt.Sprintf("The template declares output %q, which isn't declared in schema, so it's typed as a Str in typed_outputs.", k),
+			)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		if props[k].Extract == nil {
+			continue
+		}
+		extraction, err := parseOutputExtraction(*props[k].Extract)
+		if err != nil {
+			// Reported by propDeclsValidator.
+			continue
+		}
+		if parsed.Objects == nil {
+			diags.AddAttributeError(
+				path.Root("schema").AtMapKey(k).AtName("extract"),
+				"Unsupported Output Extraction",
+				fmt.Sprintf("%s templates declare their outputs, so property %q can't be extracted from an object.", templateFmt, k),
+			)
+		} else if _, found := slices.BinarySearch(parsed.Objects, extraction.Object); !found {
+			diags.AddAttributeError(
+				path.Root("schema").AtMapKey(k).AtName("extract"),
+				"Unknown Output Object",
+				fmt.Sprintf("Property %q is extracted from %s, which the template doesn't create; it creates %s.",
+					k, extraction.Object, strings.Join(parsed.Objects, ", ")),
+			)
+		}
+	}
+
+	return diags
+}
+
+func propTypeList(propTypes []TfRsxPropType) string {
+	var names []string
+	for _, propType := range propTypes {
+		names = append(names, string(propType))
+	}
+	return strings.Join(names, ", ")
+}
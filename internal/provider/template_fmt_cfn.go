@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The top level sections of CloudFormation templates.
+var cloudFormationSections = []string{
+	"AWSTemplateFormatVersion", "Conditions", "Description", "Mappings", "Metadata", "Outputs", "Parameters", "Resources",
+	"Rules", "Transform",
+}
+
+// cloudFormationFmt parses CloudFormation templates, written in JSON or YAML. The Parameters of a template are the vars
+// that it declares, and its Outputs are the outputs it declares.
+type cloudFormationFmt struct {
+	yaml bool
+}
+
+func (f cloudFormationFmt) Parse(template string) (*parsedTemplate, error) {
+	var v any
+	if f.yaml {
+		docs, err := decodeYamlDocuments(template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if len(docs) != 1 {
+			return nil, fmt.Errorf("expected a single YAML document, got %d", len(docs))
+		}
+		v = docs[0]
+	} else {
+		var err error
+		if v, err = decodeJson(template); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+	root, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", jsonKind(v))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(root)) {
+		if !slices.Contains(cloudFormationSections, key) {
+			return nil, fmt.Errorf("unsupported top level key %q", key)
+		}
+	}
+	resources, ok := root["Resources"].(map[string]any)
+	if !ok || len(resources) == 0 {
+		return nil, errors.New("Resources: expected an object of at least one resource")
+	}
+
+	parsed := newParsedTemplate()
+	if params, ok := root["Parameters"]; ok {
+		decls, ok := params.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("Parameters: expected an object of parameters, got %s", jsonKind(params))
+		}
+		for name, decl := range decls {
+			declObj, ok := decl.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("Parameters.%s: expected an object, got %s", name, jsonKind(decl))
+			}
+			paramType, ok := declObj["Type"].(string)
+			if !ok {
+				return nil, fmt.Errorf("Parameters.%s: expected a Type", name)
+			}
+			_, hasDefault := declObj["Default"]
+			noEcho, _ := declObj["NoEcho"].(string)
+			if b, ok := declObj["NoEcho"].(bool); ok && b {
+				noEcho = "true"
+			}
+			parsed.Params[name] = templateParam{
+				HasDefault: hasDefault,
+				Type:       paramType,
+				PropTypes:  cloudFormationPropTypes(paramType),
+				Sensitive:  strings.EqualFold(noEcho, "true"),
+			}
+		}
+	}
+	if outputs, ok := root["Outputs"]; ok {
+		decls, ok := outputs.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("Outputs: expected an object of outputs, got %s", jsonKind(outputs))
+		}
+		for name := range decls {
+			parsed.addOutput(name)
+		}
+	}
+
+	// Refs to resources and pseudo parameters aren't refs to vars.
+	addRef := func(name string) {
+		if _, ok := resources[name]; ok || strings.HasPrefix(name, "AWS::") {
+			return
+		}
+		parsed.addRef(name)
+	}
+	walkCloudFormationRefs(root, addRef)
+	return parsed, nil
+}
+
+// cloudFormationPropTypes returns the property types that a var of the given CloudFormation parameter type may be
+// declared as.
+func cloudFormationPropTypes(paramType string) []TfRsxPropType {
+	switch {
+	case paramType == "Number":
+		return []TfRsxPropType{I32, I64, F32, F64}
+	case paramType == "List<Number>":
+		return []TfRsxPropType{ListOf(I32), ListOf(I64), ListOf(F32), ListOf(F64), Str}
+	case paramType == "CommaDelimitedList" || strings.HasPrefix(paramType, "List<") ||
+		strings.HasPrefix(paramType, "AWS::SSM::Parameter::Value<List<"):
+		// Lists may also be passed as comma delimited strings.
+		return []TfRsxPropType{ListOf(Str), Str}
+	default:
+		return []TfRsxPropType{Str, Secret}
+	}
+}
+
+// The ${Name} variables of Fn::Sub strings; ${!Literal}s are escaped, and ${Resource.Attribute}s are attributes.
+var cloudFormationSubVar = regexp.MustCompile(`\$\{([^!}][^}]*)\}`)
+
+// walkCloudFormationRefs calls addRef with the names that a CloudFormation value references with Ref and Fn::Sub.
+func walkCloudFormationRefs(v any, addRef func(name string)) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			walkCloudFormationRefs(item, addRef)
+		}
+	case map[string]any:
+		if ref, ok := v["Ref"].(string); ok && len(v) == 1 {
+			addRef(ref)
+			return
+		}
+		if sub, ok := v["Fn::Sub"]; ok && len(v) == 1 {
+			// The second argument of the list form defines variables local to the string.
+			var locals map[string]any
+			s, isString := sub.(string)
+			if args, ok := sub.([]any); ok && len(args) == 2 {
+				s, isString = args[0].(string)
+				if vars, ok := args[1].(map[string]any); ok {
+					walkCloudFormationRefs(vars, addRef)
+					locals = vars
+				}
+			}
+			if isString {
+				for _, match := range cloudFormationSubVar.FindAllStringSubmatch(s, -1) {
+					name := strings.TrimSpace(match[1])
+					if _, ok := locals[name]; !ok && !strings.Contains(name, ".") {
+						addRef(name)
+					}
+				}
+			}
+			return
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			walkCloudFormationRefs(v[k], addRef)
+		}
+	}
+}
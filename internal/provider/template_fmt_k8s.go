@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// kubernetesFmt parses Kubernetes manifests: YAML streams of objects, e.g. as rendered by helm template. Manifests
+// don't declare their vars; instead, every ${name} substitution is a var, which ${name:-default} and ${name:=default}
+// default. $${name} escapes a literal ${name}.
+type kubernetesFmt struct{}
+
+func (f kubernetesFmt) Parse(template string) (*parsedTemplate, error) {
+	parsed := newParsedTemplate()
+	if err := addKubernetesSubstitutions(parsed, template); err != nil {
+		return nil, err
+	}
+
+	docs, err := decodeYamlDocuments(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	parsed.Objects = []string{}
+	for i, doc := range docs {
+		if doc == nil {
+			// Empty documents, e.g. of templates that helm didn't render anything for.
+			continue
+		}
+		id, err := kubernetesObjectIdOf(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		if !parsed.addObject(id) {
+			return nil, fmt.Errorf("document %d: duplicate object %s", i+1, id)
+		}
+	}
+	if len(parsed.Objects) == 0 {
+		return nil, errors.New("expected at least one object")
+	}
+	return parsed, nil
+}
+
+// The ${...} substitutions of manifests, along with any $s escaping them.
+var kubernetesSubstitution = regexp.MustCompile(`(\$+)\{([^}]*)\}`)
+
+var kubernetesSubstitutionVar = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(:?[-=].*)?$`)
+
+func addKubernetesSubstitutions(parsed *parsedTemplate, template string) error {
+	for _, match := range kubernetesSubstitution.FindAllStringSubmatchIndex(template, -1) {
+		if (match[3]-match[2])%2 == 0 {
+			// Escaped.
+			continue
+		}
+		expr := template[match[4]:match[5]]
+		varMatch := kubernetesSubstitutionVar.FindStringSubmatch(expr)
+		if varMatch == nil {
+			line := strings.Count(template[:match[0]], "\n") + 1
+			return fmt.Errorf("line %d: invalid substitution ${%s}; expected ${name}, ${name:-default} or ${name:=default}", line, expr)
+		}
+
+		name, hasDefault := varMatch[1], varMatch[2] != ""
+		if param, ok := parsed.Params[name]; ok {
+			// The var must be passed unless every substitution of it defaults it.
+			hasDefault = hasDefault && param.HasDefault
+		}
+		parsed.Params[name] = templateParam{HasDefault: hasDefault}
+		parsed.addRef(name)
+	}
+	return nil
+}
+
+// kubernetesObjectIdOf returns the identity of a Kubernetes object: its kind, namespace and name, as
+// Kind/namespace/name, or Kind/name if it has no namespace.
+func kubernetesObjectIdOf(doc any) (string, error) {
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("expected an object, got %s", jsonKind(doc))
+	}
+	if apiVersion, _ := obj["apiVersion"].(string); apiVersion == "" {
+		return "", errors.New("expected an apiVersion")
+	}
+	kind, _ := obj["kind"].(string)
+	if kind == "" {
+		return "", errors.New("expected a kind")
+	}
+	metadata, _ := obj["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	if name == "" {
+		return "", fmt.Errorf("expected a metadata.name for the %s", kind)
+	}
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		return kind + "/" + namespace + "/" + name, nil
+	}
+	return kind + "/" + name, nil
+}
+
+// kubernetesObjects keys the documents of a manifest by the identity of their objects, so that manifests whose objects
+// are the same compare equal regardless of the order they're in.
+func kubernetesObjects(docs []any) (map[string]any, bool) {
+	result := make(map[string]any)
+	for _, doc := range docs {
+		id, err := kubernetesObjectIdOf(doc)
+		if err != nil {
+			return nil, false
+		}
+		if _, ok := result[id]; ok {
+			return nil, false
+		}
+		result[id] = doc
+	}
+	return result, true
+}
+
+// outputExtraction is where the output of a property is extracted from: an object of the template and a path into it.
+type outputExtraction struct {
+	Object string
+	Path   []any
+}
+
+var outputExtractionPathSegment = regexp.MustCompile(`^(?:\.([A-Za-z0-9_-]+)|\[([0-9]+)\]|\["([^"]+)"\])`)
+
+// parseOutputExtraction parses the extract of a property, e.g. Service/default/web:.status.loadBalancer.ingress[0].ip.
+// Path segments are .key, ["key"] for keys that aren't plain words, or [index].
+func parseOutputExtraction(s string) (*outputExtraction, error) {
+	object, path, found := strings.Cut(s, ":")
+	if !found || path == "" {
+		return nil, fmt.Errorf("%q isn't of the form Kind/name:.path or Kind/namespace/name:.path", s)
+	}
+	if parts := strings.Split(object, "/"); len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("%q isn't an object of the form Kind/name or Kind/namespace/name", object)
+	}
+
+	extraction := &outputExtraction{Object: object}
+	for rest := path; rest != ""; {
+		match := outputExtractionPathSegment.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, fmt.Errorf("%q isn't a path of .key, [\"key\"] and [index] segments", path)
+		}
+		switch {
+		case match[1] != "":
+			extraction.Path = append(extraction.Path, match[1])
+		case match[2] != "":
+			index, err := strconv.Atoi(match[2])
+			if err != nil {
+				return nil, err
+			}
+			extraction.Path = append(extraction.Path, index)
+		default:
+			extraction.Path = append(extraction.Path, match[3])
+		}
+		rest = rest[len(match[0]):]
+	}
+	return extraction, nil
+}
@@ -0,0 +1,477 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestParseTemplate(t *testing.T) {
+	cases := []struct {
+		name        string
+		templateFmt TfTemplateFmt
+		template    string
+		expected    *parsedTemplate
+		err         string
+	}{
+		{
+			name:        "terraform",
+			templateFmt: TerraformFmt,
+			template: `
+variable "name" {}
+variable "size" {
+  default = 1
+}
+resource "aws_s3_bucket" "b" {
+  bucket = "${var.name}-${var.size}"
+  tags   = { owner = var.owner }
+}
+output "arn" {
+  value = aws_s3_bucket.b.arn
+}
+`,
+			expected: &parsedTemplate{
+				Params:  map[string]templateParam{"name": {}, "size": {HasDefault: true}},
+				Refs:    []string{"name", "owner", "size"},
+				Outputs: []string{"arn"},
+			},
+		},
+		{
+			name:        "terraform syntax error",
+			templateFmt: TerraformFmt,
+			template:    `resource "aws_s3_bucket" "b" {`,
+			err:         "template.tf:1",
+		},
+		{
+			name:        "terraform top level argument",
+			templateFmt: TerraformFmt,
+			template:    `name = "x"`,
+			err:         `unexpected argument "name"`,
+		},
+		{
+			name:        "terraform unknown block",
+			templateFmt: TerraformFmt,
+			template:    `resources "aws_s3_bucket" "b" {}`,
+			err:         `unsupported block type "resources"`,
+		},
+		{
+			name:        "terraform json",
+			templateFmt: TerraformJsonFmt,
+			template: `{
+  "variable": {"name": {}, "size": {"default": 1}},
+  "resource": {"aws_s3_bucket": {"b": {"bucket": "${var.name}", "tags": ["${upper(var.owner)}"]}}},
+  "output": {"arn": {"value": "${aws_s3_bucket.b.arn}"}}
+}`,
+			expected: &parsedTemplate{
+				Params:  map[string]templateParam{"name": {}, "size": {HasDefault: true}},
+				Refs:    []string{"name", "owner"},
+				Outputs: []string{"arn"},
+			},
+		},
+		{
+			name:        "terraform json empty",
+			templateFmt: TerraformJsonFmt,
+			template:    `{}`,
+			expected:    newParsedTemplate(),
+		},
+		{
+			name:        "terraform json not an object",
+			templateFmt: TerraformJsonFmt,
+			template:    `[]`,
+			err:         "expected a JSON object",
+		},
+		{
+			name:        "terraform json unknown key",
+			templateFmt: TerraformJsonFmt,
+			template:    `{"resources": {}}`,
+			err:         `unsupported top level key "resources"`,
+		},
+		{
+			name:        "terraform json bad expression",
+			templateFmt: TerraformJsonFmt,
+			template:    `{"locals": {"a": "${var.}"}}`,
+			err:         "locals.a:",
+		},
+		{
+			name:        "cloudformation json",
+			templateFmt: CloudFormationJsonFmt,
+			template: `{
+  "Parameters": {
+    "Name": {"Type": "String"},
+    "Size": {"Type": "Number", "Default": 1},
+    "Password": {"Type": "String", "NoEcho": true}
+  },
+  "Resources": {
+    "Bucket": {"Type": "AWS::S3::Bucket", "Properties": {
+      "BucketName": {"Fn::Sub": "${Name}-${AWS::Region}-${Bucket.Arn}-${!Literal}"},
+      "Tags": [{"Key": "owner", "Value": {"Ref": "Owner"}}, {"Key": "size", "Value": {"Fn::Sub": ["${X}", {"X": {"Ref": "Size"}}]}}]
+    }}
+  },
+  "Outputs": {"Arn": {"Value": {"Fn::GetAtt": ["Bucket", "Arn"]}}, "Self": {"Value": {"Ref": "Bucket"}}}
+}`,
+			expected: &parsedTemplate{
+				Params: map[string]templateParam{
+					"Name":     {Type: "String", PropTypes: []TfRsxPropType{Str, Secret}},
+					"Size":     {HasDefault: true, Type: "Number", PropTypes: []TfRsxPropType{I32, I64, F32, F64}},
+					"Password": {Type: "String", PropTypes: []TfRsxPropType{Str, Secret}, Sensitive: true},
+				},
+				Refs:    []string{"Name", "Owner", "Size"},
+				Outputs: []string{"Arn", "Self"},
+			},
+		},
+		{
+			name:        "cloudformation yaml",
+			templateFmt: CloudFormationYamlFmt,
+			template: `
+Parameters:
+  Subnets:
+    Type: List<AWS::EC2::Subnet::Id>
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${Name}-bucket"
+      Tags:
+        - Key: subnets
+          Value: !Join [",", !Ref Subnets]
+Outputs:
+  Arn:
+    Value: !GetAtt Bucket.Arn
+`,
+			expected: &parsedTemplate{
+				Params:  map[string]templateParam{"Subnets": {Type: "List<AWS::EC2::Subnet::Id>", PropTypes: []TfRsxPropType{ListOf(Str), Str}}},
+				Refs:    []string{"Name", "Subnets"},
+				Outputs: []string{"Arn"},
+			},
+		},
+		{
+			name:        "cloudformation without resources",
+			templateFmt: CloudFormationYamlFmt,
+			template:    "Parameters: {}\n",
+			err:         "Resources: expected an object of at least one resource",
+		},
+		{
+			name:        "cloudformation yaml syntax error",
+			templateFmt: CloudFormationYamlFmt,
+			template:    "Resources:\n  Bucket: [\n",
+			err:         "invalid YAML",
+		},
+		{
+			name:        "cloudformation parameter without type",
+			templateFmt: CloudFormationJsonFmt,
+			template:    `{"Parameters": {"Name": {}}, "Resources": {"Bucket": {"Type": "AWS::S3::Bucket"}}}`,
+			err:         "Parameters.Name: expected a Type",
+		},
+		{
+			name:        "kubernetes",
+			templateFmt: KubernetesYamlFmt,
+			template: `
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: ${namespace}
+spec:
+  ports:
+    - port: ${port:-80}
+---
+# Source: web/templates/empty.yaml
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: ${namespace}
+spec:
+  replicas: ${replicas:=1}
+  template:
+    spec:
+      containers:
+        - name: web
+          args: ["--port=${port}", "--literal=$${HOME}"]
+`,
+			expected: &parsedTemplate{
+				Params: map[string]templateParam{
+					"namespace": {},
+					"port":      {},
+					"replicas":  {HasDefault: true},
+				},
+				Refs:    []string{"namespace", "port", "replicas"},
+				Objects: []string{"Deployment/${namespace}/web", "Service/${namespace}/web"},
+			},
+		},
+		{
+			name:        "kubernetes duplicate object",
+			templateFmt: KubernetesYamlFmt,
+			template:    "apiVersion: v1\nkind: Service\nmetadata: {name: web}\n---\napiVersion: v1\nkind: Service\nmetadata: {name: web}\n",
+			err:         "document 2: duplicate object Service/web",
+		},
+		{
+			name:        "kubernetes without name",
+			templateFmt: KubernetesYamlFmt,
+			template:    "apiVersion: v1\nkind: Service\nmetadata: {}\n",
+			err:         "expected a metadata.name for the Service",
+		},
+		{
+			name:        "kubernetes invalid substitution",
+			templateFmt: KubernetesYamlFmt,
+			template:    "apiVersion: v1\nkind: Service\nmetadata: {name: \"${web.name}\"}\n",
+			err:         "line 3: invalid substitution ${web.name}",
+		},
+		{
+			name:        "kubernetes empty",
+			templateFmt: KubernetesYamlFmt,
+			template:    "---\n# nothing rendered\n",
+			err:         "expected at least one object",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parsed, err := parseTemplate(c.templateFmt, c.template)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected an error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, c.expected) {
+				t.Errorf("expected %+v, got %+v", c.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestTemplateFmtValidator(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]string{
+		"Terraform":          "",
+		"TerraformJson":      "",
+		"Teraform":           `did you mean "Terraform"?`,
+		"cloudformationyaml": `did you mean "CloudFormationYaml"?`,
+		"Pulumi":             "expected one of",
+	}
+	for templateFmt, expected := range cases {
+		resp := &validator.StringResponse{}
+		templateFmtValidator{}.ValidateString(ctx, validator.StringRequest{Path: path.Root("template_fmt"), ConfigValue: types.StringValue(templateFmt)}, resp)
+
+		if expected == "" {
+			if resp.Diagnostics.HasError() {
+				t.Errorf("expected %q to be valid, got %v", templateFmt, resp.Diagnostics)
+			}
+			continue
+		}
+		if resp.Diagnostics.ErrorsCount() != 1 {
+			t.Fatalf("expected 1 error for %q, got %v", templateFmt, resp.Diagnostics)
+		}
+		if d := resp.Diagnostics.Errors()[0]; !strings.Contains(d.Detail(), expected) {
+			t.Errorf("expected %q in %s", expected, d.Detail())
+		}
+	}
+}
+
+func TestTemplateValidator(t *testing.T) {
+	def := "1"
+	schema, diags := propsValue(TfRsxProps{"size": {Type: I32, Default: &def}})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	schemaVal, err := schema.ToTerraformValue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		template string
+		errors   map[string]string
+		warnings map[string]string
+	}{
+		{
+			name: "valid",
+			template: `variable "name" {}
+variable "size" {}
+variable "password" {}
+variable "region" { default = "us-west-2" }
+locals { x = "${var.name}-${var.size}-${var.password}-${var.region}" }
+`,
+		},
+		{
+			name:     "invalid",
+			template: `variable "name" {`,
+			errors:   map[string]string{"template": "Invalid Template"},
+		},
+		{
+			name: "undeclared and missing",
+			template: `variable "name" {}
+variable "zone" {}
+locals { x = var.owner }
+`,
+			errors: map[string]string{
+				"template": "Undeclared Template Variable",
+				"vars":     "Missing Template Variable",
+			},
+		},
+		{
+			name:     "unused",
+			template: `variable "size" {}`,
+			warnings: map[string]string{"vars": "Unused Variable"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+				"template":     tftypes.NewValue(tftypes.String, c.template),
+				"template_fmt": tftypes.NewValue(tftypes.String, string(TerraformFmt)),
+				"vars":         testStringMap(map[string]string{"name": "x"}),
+				"secret_vars":  testStringMap(map[string]string{"password": "hunter2"}),
+				"schema":       schemaVal,
+			})
+
+			resp := &resource.ValidateConfigResponse{}
+			templateValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+			checkDiags := func(kind string, diags diag.Diagnostics, expected map[string]string) {
+				if len(diags) != len(expected) {
+					t.Fatalf("expected %d %s, got %v", len(expected), kind, diags)
+				}
+				for _, d := range diags {
+					p := d.(diag.DiagnosticWithPath).Path().String()
+					if expected[p] != d.Summary() {
+						t.Errorf("unexpected %s at %s: %s", kind, p, d.Summary())
+					}
+				}
+			}
+			checkDiags("errors", resp.Diagnostics.Errors(), c.errors)
+			checkDiags("warnings", resp.Diagnostics.Warnings(), c.warnings)
+		})
+	}
+}
+
+func TestTemplateValidatorCloudFormation(t *testing.T) {
+	template := `
+Parameters:
+  Name: {Type: String}
+  Size: {Type: Number}
+  Password: {Type: String, NoEcho: true}
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+Outputs:
+  Arn: {Value: !GetAtt Bucket.Arn}
+  Url: {Value: !GetAtt Bucket.WebsiteURL}
+`
+	config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+		"template":     tftypes.NewValue(tftypes.String, template),
+		"template_fmt": tftypes.NewValue(tftypes.String, string(CloudFormationYamlFmt)),
+		"vars":         testStringMap(map[string]string{"Name": "x", "Size": "1", "Password": "hunter2"}),
+		"schema":       testSchema(map[string]TfRsxPropType{"Arn": Str}),
+	})
+
+	resp := &resource.ValidateConfigResponse{}
+	templateValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+	summaries := func(diags diag.Diagnostics) []string {
+		var result []string
+		for _, d := range diags {
+			result = append(result, d.Summary())
+		}
+		return result
+	}
+	if errors := summaries(resp.Diagnostics.Errors()); !reflect.DeepEqual(errors, []string{"Incompatible Variable Type"}) {
+		t.Errorf("unexpected errors: %v", resp.Diagnostics.Errors())
+	}
+	if warnings := summaries(resp.Diagnostics.Warnings()); !reflect.DeepEqual(warnings, []string{"Sensitive Variable In vars", "Undeclared Template Output"}) {
+		t.Errorf("unexpected warnings: %v", resp.Diagnostics.Warnings())
+	}
+}
+
+func TestParseOutputExtraction(t *testing.T) {
+	cases := map[string]*outputExtraction{
+		"Service/default/web:.status.loadBalancer.ingress[0].ip": {
+			Object: "Service/default/web",
+			Path:   []any{"status", "loadBalancer", "ingress", 0, "ip"},
+		},
+		`ConfigMap/web:.data["app.properties"]`: {Object: "ConfigMap/web", Path: []any{"data", "app.properties"}},
+		"Service/default/web":                   nil,
+		"Service:.spec":                         nil,
+		"Service/a/b/c:.spec":                   nil,
+		"Service/web:spec":                      nil,
+		"Service/web:.spec[x]":                  nil,
+	}
+	for s, expected := range cases {
+		extraction, err := parseOutputExtraction(s)
+		if expected == nil {
+			if err == nil {
+				t.Errorf("expected %q to be invalid, got %+v", s, extraction)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected %q to be valid, got %s", s, err)
+		} else if !reflect.DeepEqual(extraction, expected) {
+			t.Errorf("expected %q to parse as %+v, got %+v", s, expected, extraction)
+		}
+	}
+}
+
+func TestTemplateValidatorOutputExtraction(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: Service\nmetadata: {name: web, namespace: default}\n"
+	ip, missing := "Service/default/web:.spec.clusterIP", "Service/prod/web:.spec.clusterIP"
+	schema, diags := propsValue(TfRsxProps{"ip": {Type: Str, Extract: &ip}, "missing": {Type: Str, Extract: &missing}})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	schemaVal, err := schema.ToTerraformValue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[TfTemplateFmt]map[string]string{
+		KubernetesYamlFmt: {
+			path.Root("schema").AtMapKey("missing").AtName("extract").String(): "Unknown Output Object",
+		},
+		CloudFormationYamlFmt: {
+			path.Root("schema").AtMapKey("ip").AtName("extract").String():      "Unsupported Output Extraction",
+			path.Root("schema").AtMapKey("missing").AtName("extract").String(): "Unsupported Output Extraction",
+		},
+	}
+	for templateFmt, expected := range cases {
+		template := manifest
+		if templateFmt == CloudFormationYamlFmt {
+			template = "Resources:\n  Bucket: {Type: AWS::S3::Bucket}\n"
+		}
+		config := testLoFiTwinConfig(t, map[string]tftypes.Value{
+			"template":     tftypes.NewValue(tftypes.String, template),
+			"template_fmt": tftypes.NewValue(tftypes.String, string(templateFmt)),
+			"vars":         testStringMap(map[string]string{}),
+			"schema":       schemaVal,
+		})
+
+		resp := &resource.ValidateConfigResponse{}
+		templateValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{Config: config}, resp)
+
+		if resp.Diagnostics.ErrorsCount() != len(expected) {
+			t.Fatalf("%s: expected %d errors, got %v", templateFmt, len(expected), resp.Diagnostics)
+		}
+		for _, d := range resp.Diagnostics.Errors() {
+			if p := d.(diag.DiagnosticWithPath).Path().String(); expected[p] != d.Summary() {
+				t.Errorf("%s: unexpected error at %s: %s", templateFmt, p, d.Summary())
+			}
+		}
+	}
+}
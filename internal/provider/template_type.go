@@ -0,0 +1,223 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var _ basetypes.StringTypable = TemplateType{}
+var _ basetypes.StringValuableWithSemanticEquals = TemplateValue{}
+
+// TemplateType is the type of the template attribute: a string whose values are equal when they describe the same
+// template, so that reformatting a template doesn't plan an update.
+type TemplateType struct {
+	basetypes.StringType
+}
+
+func (t TemplateType) String() string {
+	return "TemplateType"
+}
+
+func (t TemplateType) Equal(o attr.Type) bool {
+	other, ok := o.(TemplateType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t TemplateType) ValueType(_ context.Context) attr.Value {
+	return TemplateValue{}
+}
+
+func (t TemplateType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return TemplateValue{StringValue: in}, nil
+}
+
+func (t TemplateType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return TemplateValue{StringValue: stringValue}, nil
+}
+
+// TemplateValue is a value of TemplateType.
+type TemplateValue struct {
+	basetypes.StringValue
+}
+
+func NewTemplateValue(s string) TemplateValue {
+	return TemplateValue{StringValue: basetypes.NewStringValue(s)}
+}
+
+func (v TemplateValue) Type(_ context.Context) attr.Type {
+	return TemplateType{}
+}
+
+func (v TemplateValue) Equal(o attr.Value) bool {
+	other, ok := o.(TemplateValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+// StringSemanticEquals reports whether the new template only differs from this one in line endings and trailing
+// whitespace, which are insignificant in every template format. Values don't know the template_fmt of their twin rsx,
+// so reformatted templates of a given format are kept by templateModifier when planning, and by Read, where it's
+// known.
+func (v TemplateValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(TemplateValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T, got %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	return normalizeTemplateText(v.ValueString()) == normalizeTemplateText(newValue.ValueString()), diags
+}
+
+// templatesEquivalent reports whether two templates of the given format describe the same template. JSON templates are
+// equal when they decode to the same values, regardless of key order and whitespace, and YAML templates likewise
+// regardless of comments and style, with Kubernetes manifests equal when they hold the same objects in any order.
+// Templates of other formats, or that don't decode as their format, are equal when they only differ in line endings
+// and trailing whitespace.
+func templatesEquivalent(templateFmt TfTemplateFmt, a string, b string) bool {
+	if a == b {
+		return true
+	}
+
+	switch templateFmt {
+	case TerraformJsonFmt, CloudFormationJsonFmt:
+		aJson, aErr := decodeJson(a)
+		bJson, bErr := decodeJson(b)
+		if aErr == nil && bErr == nil {
+			return reflect.DeepEqual(normalizeJson(aJson), normalizeJson(bJson))
+		}
+	case CloudFormationYamlFmt, KubernetesYamlFmt:
+		aYaml, aErr := decodeYamlTemplate(a)
+		bYaml, bErr := decodeYamlTemplate(b)
+		if aErr == nil && bErr == nil {
+			if templateFmt == KubernetesYamlFmt {
+				aObjects, aOk := kubernetesObjects(aYaml)
+				bObjects, bOk := kubernetesObjects(bYaml)
+				if aOk && bOk {
+					return reflect.DeepEqual(normalizeJson(aObjects), normalizeJson(bObjects))
+				}
+			}
+			return reflect.DeepEqual(normalizeJson(aYaml), normalizeJson(bYaml))
+		}
+	}
+
+	return normalizeTemplateText(a) == normalizeTemplateText(b)
+}
+
+// templateModifier plans the prior template when the configured one only reformats it, as templatesEquivalent judges
+// by template_fmt, so that reformatting a template plans no change. Terraform accepts the prior value of an attribute
+// as planned in place of its configured value, taking the two to be equivalent.
+type templateModifier struct{}
+
+var _ planmodifier.String = templateModifier{}
+
+func (m templateModifier) Description(ctx context.Context) string {
+	return m.MarkdownDescription(ctx)
+}
+
+func (m templateModifier) MarkdownDescription(_ context.Context) string {
+	return "Keeps the prior template when the configured one is the same template of the same `template_fmt`, reformatted"
+}
+
+func (m templateModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to keep on create, nor to plan on destroy.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+	if req.PlanValue.IsNull() || req.PlanValue.IsUnknown() || req.StateValue.IsNull() {
+		return
+	}
+
+	var plannedFmt, priorFmt types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("template_fmt"), &plannedFmt)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("template_fmt"), &priorFmt)...)
+	if resp.Diagnostics.HasError() || plannedFmt.IsUnknown() || !plannedFmt.Equal(priorFmt) {
+		return
+	}
+
+	if templatesEquivalent(TfTemplateFmt(plannedFmt.ValueString()), req.PlanValue.ValueString(), req.StateValue.ValueString()) {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// decodeYamlTemplate decodes the documents of a YAML template, skipping empty ones.
+func decodeYamlTemplate(s string) ([]any, error) {
+	docs, err := decodeYamlDocuments(s)
+	if err != nil {
+		return nil, err
+	}
+	var nonEmpty []any
+	for _, doc := range docs {
+		if doc != nil {
+			nonEmpty = append(nonEmpty, doc)
+		}
+	}
+	return nonEmpty, nil
+}
+
+// normalizeJson canonicalizes the numbers in a decoded JSON value, so that e.g. 1.0 and 1 compare equal.
+func normalizeJson(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if f, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven); err == nil {
+			return f.Text('g', -1)
+		}
+		return v.String()
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalizeJson(item)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any)
+		for k, entry := range v {
+			result[k] = normalizeJson(entry)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// normalizeTemplateText strips the differences in line endings and trailing whitespace that editors and
+// heredocs introduce.
+func normalizeTemplateText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
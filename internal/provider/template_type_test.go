@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTemplateSemanticEquals(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"resource \"x\" \"y\" {\r\n  a = 1   \r\n}\r\n", "resource \"x\" \"y\" {\n  a = 1\n}", true},
+		{"resource \"x\" \"y\" {\n  a = 1\n}", "resource \"x\" \"y\" {\n    a = 1\n}", false},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, false},
+	}
+	for _, c := range cases {
+		equal, diags := NewTemplateValue(c.a).StringSemanticEquals(context.Background(), NewTemplateValue(c.b))
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if equal != c.equal {
+			t.Errorf("expected %q and %q equal=%t", c.a, c.b, c.equal)
+		}
+	}
+}
+
+func TestTemplatesEquivalent(t *testing.T) {
+	cases := []struct {
+		templateFmt TfTemplateFmt
+		a, b        string
+		equal       bool
+	}{
+		{TerraformJsonFmt, `{"a": 1, "b": [true, null]}`, "{\n  \"b\": [true, null],\n  \"a\": 1\n}\n", true},
+		{TerraformJsonFmt, `{"a": 1}`, `{"a": 1.0}`, true},
+		{TerraformJsonFmt, `{"a": 1}`, `{"a": 2}`, false},
+		{TerraformJsonFmt, `{"a": [1, 2]}`, `{"a": [2, 1]}`, false},
+		{TerraformJsonFmt, `{"a": 1}`, "a: 1", false},
+		{CloudFormationJsonFmt, `{"Resources": {"A": {}, "B": {}}}`, `{"Resources": {"B": {}, "A": {}}}`, true},
+		{TerraformFmt, "resource \"x\" \"y\" {\r\n  a = 1   \r\n}\r\n", "resource \"x\" \"y\" {\n  a = 1\n}", true},
+		{TerraformFmt, "resource \"x\" \"y\" {\n  a = 1\n}", "resource \"x\" \"y\" {\n    a = 1\n}", false},
+		// HCL that happens to parse as the same YAML mapping, {`a = "x`: `1"`}, is still compared as HCL.
+		{TerraformFmt, `a = "x: 1"`, `a = "x:  1"`, false},
+		{TerraformFmt, `{"a": 1}`, `{"a": 1.0}`, false},
+		{CloudFormationYamlFmt, "a: 1\nb:\n  - x # comment\n", "b: [x]\na: 1.0", true},
+		{CloudFormationYamlFmt, "a: 1\nb:\n  - x\n", `{"a": 1, "b": ["x"]}`, true},
+		{CloudFormationYamlFmt, "a: !Ref Name\nb: !GetAtt Bucket.Arn", "a:\n  Ref: Name\nb:\n  Fn::GetAtt: [Bucket, Arn]", true},
+		{CloudFormationYamlFmt, "a: 1\n---\nb: 2\n", "b: 2\n---\na: 1\n", false},
+		{KubernetesYamlFmt, "a: 1\n---\nb: 2\n", "a: 1\n---\nb: 3\n", false},
+		{
+			KubernetesYamlFmt,
+			"apiVersion: v1\nkind: Service\nmetadata: {name: web}\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\n",
+			"---\napiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\n---\napiVersion: v1\nkind: Service\nmetadata: {name: web}\n",
+			true,
+		},
+		{
+			KubernetesYamlFmt,
+			"apiVersion: v1\nkind: Service\nmetadata: {name: web}\n",
+			"apiVersion: v1\nkind: Service\nmetadata: {name: web, namespace: prod}\n",
+			false,
+		},
+	}
+	for _, c := range cases {
+		if equal := templatesEquivalent(c.templateFmt, c.a, c.b); equal != c.equal {
+			t.Errorf("expected %s templates %q and %q equal=%t", c.templateFmt, c.a, c.b, c.equal)
+		}
+	}
+}
+
+func TestTemplateModifier(t *testing.T) {
+	prior := `{"resource": {"a": {}, "b": {}}}`
+	cases := []struct {
+		name        string
+		template    string
+		templateFmt string
+		expected    string
+	}{
+		{name: "keys reordered", template: "{\n  \"resource\": {\"b\": {}, \"a\": {}}\n}\n", templateFmt: "TerraformJson", expected: prior},
+		{name: "changed", template: `{"resource": {"a": {}}}`, templateFmt: "TerraformJson", expected: `{"resource": {"a": {}}}`},
+		{name: "format changed", template: prior, templateFmt: "CloudFormationJson", expected: prior},
+		{name: "format changed and keys reordered", template: `{"resource": {"b": {}, "a": {}}}`, templateFmt: "CloudFormationJson", expected: `{"resource": {"b": {}, "a": {}}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			state := testLoFiTwinConfig(t, map[string]tftypes.Value{
+				"template":     tftypes.NewValue(tftypes.String, prior),
+				"template_fmt": tftypes.NewValue(tftypes.String, "TerraformJson"),
+			})
+			plan := testLoFiTwinConfig(t, map[string]tftypes.Value{
+				"template":     tftypes.NewValue(tftypes.String, c.template),
+				"template_fmt": tftypes.NewValue(tftypes.String, c.templateFmt),
+			})
+
+			req := planmodifier.StringRequest{
+				Path:        path.Root("template"),
+				Config:      plan,
+				Plan:        tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+				State:       tfsdk.State{Schema: state.Schema, Raw: state.Raw},
+				ConfigValue: types.StringValue(c.template),
+				PlanValue:   types.StringValue(c.template),
+				StateValue:  types.StringValue(prior),
+			}
+			resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+			templateModifier{}.PlanModifyString(ctx, req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if resp.PlanValue.ValueString() != c.expected {
+				t.Errorf("expected %q planned, got %q", c.expected, resp.PlanValue.ValueString())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeYamlDocuments decodes each document of a YAML stream into the values that decodeJson decodes JSON into, so
+// that YAML and JSON templates are handled alike: numbers are json.Numbers, mappings are map[string]any and sequences
+// are []any. CloudFormation's short form intrinsic functions, e.g. !Ref, decode as their long form, e.g. {"Ref": ...}.
+func decodeYamlDocuments(s string) ([]any, error) {
+	dec := yaml.NewDecoder(strings.NewReader(s))
+	var docs []any
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); errors.Is(err, io.EOF) {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		doc, err := fromYamlNode(&node)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func fromYamlNode(node *yaml.Node) (any, error) {
+	if fn, ok := yamlIntrinsicFunction(node.Tag); ok {
+		return fromYamlIntrinsic(fn, node)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return fromYamlNode(node.Content[0])
+	case yaml.AliasNode:
+		return fromYamlNode(node.Alias)
+	case yaml.SequenceNode:
+		result := make([]any, len(node.Content))
+		for i, item := range node.Content {
+			v, err := fromYamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = v
+		}
+		return result, nil
+	case yaml.MappingNode:
+		result := make(map[string]any)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: expected a scalar key", key.Line)
+			}
+			if _, ok := result[key.Value]; ok {
+				return nil, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+			}
+			v, err := fromYamlNode(value)
+			if err != nil {
+				return nil, err
+			}
+			result[key.Value] = v
+		}
+		return result, nil
+	default:
+		return fromYamlScalar(node)
+	}
+}
+
+func fromYamlScalar(node *yaml.Node) (any, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int", "!!float":
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(v)), nil
+	default:
+		return node.Value, nil
+	}
+}
+
+// yamlIntrinsicFunction returns the long form name of the intrinsic function that a CloudFormation short form tag
+// stands for, e.g. "Fn::GetAtt" for !GetAtt.
+func yamlIntrinsicFunction(tag string) (string, bool) {
+	if !strings.HasPrefix(tag, "!") || strings.HasPrefix(tag, "!!") {
+		return "", false
+	}
+	switch name := tag[1:]; name {
+	case "Ref", "Condition":
+		return name, true
+	default:
+		return "Fn::" + name, true
+	}
+}
+
+func fromYamlIntrinsic(fn string, node *yaml.Node) (any, error) {
+	untagged := *node
+	untagged.Tag = ""
+	if node.Kind == yaml.ScalarNode {
+		untagged.Tag = "!!str"
+	}
+	v, err := fromYamlNode(&untagged)
+	if err != nil {
+		return nil, err
+	}
+	// !GetAtt takes its resource and attribute as a single "Resource.Attribute" string.
+	if s, ok := v.(string); ok && fn == "Fn::GetAtt" {
+		if resource, attribute, found := strings.Cut(s, "."); found {
+			v = []any{resource, attribute}
+		}
+	}
+	return map[string]any{fn: v}, nil
+}